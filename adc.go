@@ -3,7 +3,13 @@
 package pico
 
 import (
+	// Module imports
+	rp "device/rp"
+	interrupt "runtime/interrupt"
+	"time"
+
 	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
 	. "github.com/djthorpe/go-pico/pkg/sdk"
 )
 
@@ -13,15 +19,54 @@ import (
 // ADC represents an Analog to Digital Converter. On the RP2040, there are
 // four ADC's.
 type ADC struct {
-	Pin  Pin
-	Num  uint32
-	temp bool
+	Pin Pin
+	Num uint32
+}
+
+type ADC_callback_t func(adc *ADC)
+
+type adc_config_t struct {
+	cal     Calibration
+	filter  Filter
+	samples int
+	buf     []uint16 // Samples for the filter, allocated by SetFilter
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	ADC_BUFFER_SIZE = 256              // Number of samples buffered by the interrupt handler
+	ADC_TEMP_SETTLE = time.Millisecond // Time for the temperature sensor to settle once enabled
+)
+
+var (
+	adc_intr     = interrupt.New(rp.IRQ_ADC_IRQ_FIFO, adc_intr_handler)
+	adc_ring     = _NewRing(ADC_BUFFER_SIZE)
+	adc_callback ADC_callback_t
+	adc_current  *ADC
+	adc_skipped  bool
+	adc_config   [NUM_ADC_CHANNELS]adc_config_t
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Return the ADC channel connected to the internal temperature sensor, or nil
+// if the ADC could not be initialised
+func TemperatureADC() *ADC {
+	if adc, err := _GPIO.temp(); err != nil {
+		return nil
+	} else {
+		return adc
+	}
 }
 
 //////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
-// Get returns the raw ADC value, which is the first 12 bits
+// Get returns the raw ADC value, which is the first 12 bits. It blocks until
+// the conversion is complete, use GetTimeout in order to detect a timeout.
 func (a *ADC) Get() uint16 {
 	ADC_select_input(a.Num)
 	for {
@@ -32,23 +77,204 @@ func (a *ADC) Get() uint16 {
 	return ADC_read()
 }
 
+// GetTimeout returns the raw ADC value, waiting for any conversion in progress
+// to complete and then for a new conversion. Returns ErrTimeout if either does
+// not complete within the timeout.
+func (a *ADC) GetTimeout(timeout time.Duration) (uint16, error) {
+	if err := ADC_wait_ready(timeout); err != nil {
+		return 0, err
+	}
+	ADC_select_input(a.Num)
+	return ADC_read_timeout(timeout)
+}
+
+// Set the rate of free-running conversions in samples per second, and return
+// the achieved rate. The rate is shared by all channels. Rates above
+// ADC_MAX_SAMPLE_RATE or below ADC_MIN_SAMPLE_RATE are clamped, and a rate of
+// zero converts at the maximum rate.
+func (a *ADC) SetSampleRate(rate uint32) uint32 {
+	return ADC_set_sample_rate(rate)
+}
+
 // Return voltage given the value of the reference voltage
 func (a *ADC) GetVoltage(vref float32) float32 {
 	return float32(a.Get()) * vref / float32(1<<12)
 }
 
+// Set the calibration for the channel, which is applied by Voltage
+func (a *ADC) SetCalibration(c Calibration) {
+	adc_config[a.Num].cal = c
+}
+
+// Return the calibration for the channel
+func (a *ADC) Calibration() Calibration {
+	return adc_config[a.Num].cal
+}
+
+// Set the filter and the number of samples combined into each reading, which
+// is between 1 and ADC_MAX_FILTER_SAMPLES. Decimation requires a power of four
+// samples, for one extra bit of resolution with each power.
+func (a *ADC) SetFilter(f Filter, samples int) error {
+	if err := assert(f <= FilterDecimate, ErrBadParameter.With("SetFilter:", f)); err != nil {
+		return err
+	}
+	if err := assert(samples >= 1 && samples <= ADC_MAX_FILTER_SAMPLES, ErrBadParameter.With("SetFilter:", samples)); err != nil {
+		return err
+	}
+	if f == FilterDecimate {
+		if err := assert(samples >= 4 && samples&(samples-1) == 0 && samples&0x5555 != 0, ErrBadParameter.With("SetFilter:", samples)); err != nil {
+			return err
+		}
+	}
+	adc_config[a.Num].filter, adc_config[a.Num].samples = f, samples
+	if f == FilterNone {
+		adc_config[a.Num].buf = nil
+	} else {
+		adc_config[a.Num].buf = make([]uint16, samples)
+	}
+	return nil
+}
+
+// Return a reading of the channel through the filter, in units of the raw
+// value
+func (a *ADC) Reading() float32 {
+	cfg := &adc_config[a.Num]
+	if cfg.filter == FilterNone {
+		return float32(a.Get())
+	}
+	for i := range cfg.buf {
+		cfg.buf[i] = a.Get()
+	}
+	return cfg.filter.Apply(cfg.buf)
+}
+
+// Return a reading of the channel through the filter, as a voltage corrected
+// by the calibration
+func (a *ADC) Voltage() float32 {
+	return adc_config[a.Num].cal.Voltage(a.Reading())
+}
+
 // Return temperature ReadTemperature does a one-shot sample of the internal
-// temperature sensor and returns a celsius reading.
+// temperature sensor and returns a celsius reading, using the typical values
+// from the datasheet. Use Temperature for a calibrated reading.
 //
 // Only works on channel five. Other channels will return 0
 func (a *ADC) GetTemperature() float32 {
 	if a.Num != ADC_temperature_input() {
 		return 0
 	}
-	if a.temp == false {
+	adc_temp_enable()
+	return TemperatureCalibration{}.Celsius(a.GetVoltage(ADC_DEFAULT_VREF))
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - INTERRUPTS
+
+// Set interrupt handler
+//
+// Starts free-running conversions on the channel, at the rate set by
+// SetSampleRate. The handler is called when at least threshold samples (between
+// 1 and ADC_FIFO_DEPTH) have been converted, after they have been moved from the
+// FIFO into a buffer, which can then be read with Read. Only one channel can
+// use interrupts at a time.
+//
+// If called with nil then conversions are stopped and the handler is disabled
+func (a *ADC) SetInterrupt(threshold uint8, handler ADC_callback_t) error {
+	// Stop conversions on any channel
+	adc_intr.Disable()
+	ADC_irq_set_enabled(false)
+	ADC_run(false)
+	ADC_fifo_drain()
+	ADC_fifo_setup(false, false, 0, false, false)
+	adc_callback, adc_current = nil, nil
+	if handler == nil {
+		return nil
+	}
+
+	// Check parameters
+	if err := assert(threshold >= 1 && threshold <= ADC_FIFO_DEPTH, ErrBadParameter.With("SetInterrupt:", threshold)); err != nil {
+		return err
+	}
+
+	// Set callback and empty the buffer
+	adc_ring.reset()
+	adc_callback, adc_current, adc_skipped = handler, a, false
+
+	// Enable the temperature sensor if it is being sampled
+	if a.Num == ADC_temperature_input() {
+		adc_temp_enable()
+	}
+
+	// Enable interrupt when the FIFO reaches the threshold
+	ADC_select_input(a.Num)
+	ADC_set_round_robin(0)
+	ADC_fifo_setup(true, false, uint16(threshold), false, false)
+	ADC_fifo_get_errors()
+	ADC_irq_set_enabled(true)
+
+	// Enable ARM interrupt and start conversions
+	adc_intr.Enable()
+	ADC_run(true)
+
+	// Return success
+	return nil
+}
+
+// Read samples which have been buffered by the interrupt handler, and return
+// the number of samples read. ErrSampleSkipped is returned if samples have been
+// dropped since the last read, because the buffer or FIFO was full.
+func (a *ADC) Read(buf []uint16) (int, error) {
+	if err := assert(a.irq(), ErrNotInitialised.With("Read")); err != nil {
+		return 0, err
+	}
+	n := adc_ring.read(buf)
+	if adc_skipped {
+		adc_skipped = false
+		return n, ErrSampleSkipped.With("Read")
+	}
+	return n, nil
+}
+
+// Return the number of samples which have been buffered by the interrupt
+// handler and not yet read
+func (a *ADC) Buffered() int {
+	if !a.irq() {
+		return 0
+	}
+	return adc_ring.len()
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Enable the temperature sensor, and wait for it to settle if it was
+// not already enabled
+func adc_temp_enable() {
+	if !ADC_is_temp_sensor_enabled() {
 		ADC_set_temp_sensor_enabled(true)
-		a.temp = true
+		time.Sleep(ADC_TEMP_SETTLE)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS - INTERRUPTS
+
+// Return true if the channel is using interrupts
+func (a *ADC) irq() bool {
+	return adc_current != nil && adc_current.Num == a.Num
+}
+
+// Interrupt handler, which drains the FIFO into the buffer
+func adc_intr_handler(interrupt.Interrupt) {
+	for !ADC_fifo_is_empty() {
+		if !adc_ring.push(ADC_fifo_get()) {
+			adc_skipped = true
+		}
+	}
+	if over, _ := ADC_fifo_get_errors(); over {
+		adc_skipped = true
+	}
+	if fn := adc_callback; fn != nil {
+		fn(adc_current)
 	}
-	// Section 4.9.5
-	return 27 - (a.GetVoltage(3.3)-0.706)/0.001721
 }
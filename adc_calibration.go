@@ -0,0 +1,101 @@
+package pico
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Calibration corrects readings for the offset and gain error of a channel,
+// and for the differential non-linearity (DNL) of the ADC. The zero value
+// applies no correction, with a reference voltage of ADC_DEFAULT_VREF.
+type Calibration struct {
+	Vref   float32 // Reference voltage, or zero for ADC_DEFAULT_VREF
+	Offset float32 // Offset error in units of the raw value
+	Gain   float32 // Gain correction, or zero for no correction
+	DNL    []DNL   // Codes with a width error, in ascending order
+}
+
+// DNL is the width error of a single code in units of the raw value, which is
+// positive when the code is wider than one step
+type DNL struct {
+	Code  uint16
+	Error float32
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	ADC_DEFAULT_VREF = 3.3     // Reference voltage on the Pico
+	ADC_RESOLUTION   = 1 << 12 // Number of codes
+)
+
+var (
+	// Codes at which the RP2040 ADC has DNL spikes (erratum RP2040-E11). The
+	// width error varies between devices, and should be measured.
+	ADC_DNL_CODES = [...]uint16{512, 1536, 2560, 3584}
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return a copy of the calibration with offset and gain set from two
+// readings of known voltages, which should be far apart
+func (c Calibration) Calibrate(reading1, v1, reading2, v2 float32) Calibration {
+	r1, r2 := c.linear(reading1), c.linear(reading2)
+	if r1 == r2 {
+		return c
+	}
+	c1, c2 := v1*ADC_RESOLUTION/c.vref(), v2*ADC_RESOLUTION/c.vref()
+	c.Gain = (c2 - c1) / (r2 - r1)
+	c.Offset = r1 - c1/c.Gain
+	return c
+}
+
+// Return a copy of the calibration with the offset set from one reading of a
+// known voltage, keeping the existing gain
+func (c Calibration) CalibrateOffset(reading, v float32) Calibration {
+	c.Offset = c.linear(reading) - v*ADC_RESOLUTION/c.vref()/c.gain()
+	return c
+}
+
+// Return the corrected value of a reading, in units of the raw value
+func (c Calibration) Value(reading float32) float32 {
+	return (c.linear(reading) - c.Offset) * c.gain()
+}
+
+// Return the corrected voltage of a reading
+func (c Calibration) Voltage(reading float32) float32 {
+	return c.Value(reading) * c.vref() / ADC_RESOLUTION
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Return a reading corrected for DNL. Codes which are wider than one step
+// shift all the codes above them, and readings within a wide code are
+// corrected in proportion.
+func (c Calibration) linear(reading float32) float32 {
+	value := reading
+	for _, d := range c.DNL {
+		code := float32(d.Code)
+		if reading >= code+0.5 {
+			value += d.Error
+		} else if reading > code-0.5 {
+			value += d.Error * (reading - code + 0.5)
+		}
+	}
+	return value
+}
+
+func (c Calibration) vref() float32 {
+	if c.Vref == 0 {
+		return ADC_DEFAULT_VREF
+	}
+	return c.Vref
+}
+
+func (c Calibration) gain() float32 {
+	if c.Gain == 0 {
+		return 1
+	}
+	return c.Gain
+}
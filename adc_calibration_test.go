@@ -0,0 +1,68 @@
+package pico_test
+
+import (
+	"math"
+	"testing"
+
+	// Namespace import
+	. "github.com/djthorpe/go-pico"
+)
+
+func Test_Calibration_001(t *testing.T) {
+	// Zero value applies no correction
+	c := Calibration{}
+	if v := c.Value(1234); v != 1234 {
+		t.Errorf("expected 1234, got %v", v)
+	}
+	if v := c.Voltage(ADC_RESOLUTION / 2); v != ADC_DEFAULT_VREF/2 {
+		t.Errorf("expected %v, got %v", ADC_DEFAULT_VREF/2, v)
+	}
+}
+
+func Test_Calibration_002(t *testing.T) {
+	// A channel which reads 2% high with an offset of 10 LSB, calibrated
+	// with readings at 0.5V and 2.5V
+	reading := func(v float32) float32 {
+		return v*ADC_RESOLUTION/ADC_DEFAULT_VREF*1.02 + 10
+	}
+	c := Calibration{}.Calibrate(reading(0.5), 0.5, reading(2.5), 2.5)
+	for _, v := range []float32{0.1, 1.0, 1.65, 3.0} {
+		if got := c.Voltage(reading(v)); math.Abs(float64(got-v)) > 1e-4 {
+			t.Errorf("expected %v, got %v", v, got)
+		}
+	}
+}
+
+func Test_Calibration_003(t *testing.T) {
+	// One-point calibration of offset keeps the gain
+	c := Calibration{Gain: 0.5}.CalibrateOffset(1000, 1.0)
+	if c.Gain != 0.5 {
+		t.Errorf("expected gain to be kept, got %v", c.Gain)
+	}
+	if v := c.Voltage(1000); math.Abs(float64(v-1.0)) > 1e-4 {
+		t.Errorf("expected 1.0, got %v", v)
+	}
+}
+
+func Test_Calibration_004(t *testing.T) {
+	// Codes above a wide code are shifted by the width error, and readings
+	// within it are corrected in proportion
+	c := Calibration{DNL: []DNL{{Code: ADC_DNL_CODES[0], Error: 8}, {Code: ADC_DNL_CODES[1], Error: 6}}}
+	tests := []struct {
+		reading, expected float32
+	}{
+		{0, 0},
+		{511, 511},
+		{511.5, 511.5},
+		{512, 516},
+		{513, 521},
+		{1535, 1543},
+		{1536, 1547},
+		{4095, 4109},
+	}
+	for _, test := range tests {
+		if v := c.Value(test.reading); v != test.expected {
+			t.Errorf("reading %v: expected %v, got %v", test.reading, test.expected, v)
+		}
+	}
+}
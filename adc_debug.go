@@ -15,3 +15,15 @@ func (v *ADC) String() string {
 	str += fmt.Sprint(" ch=", v.Num)
 	return str + ">"
 }
+
+func (v *Sampler) String() string {
+	str := "<sampler"
+	str += fmt.Sprint(" ch=", v.ch)
+	if v.rate > 0 {
+		str += fmt.Sprint(" rate=", v.rate)
+	}
+	if v.run {
+		str += " running"
+	}
+	return str + ">"
+}
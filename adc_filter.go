@@ -0,0 +1,96 @@
+package pico
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Filter determines how several raw samples are combined into one reading
+type Filter uint8
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	FilterNone     Filter = iota // Use a single sample
+	FilterAverage                // Mean of the samples
+	FilterMedian                 // Median of the samples, which rejects spikes
+	FilterDecimate               // Oversample 4^n samples, for n extra bits
+)
+
+const (
+	ADC_MAX_FILTER_SAMPLES = 256 // Maximum number of samples for one reading
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Apply the filter to a set of raw samples, and return a reading in units of
+// the 12-bit raw value. The median filter sorts the samples in place.
+// Decimation discards samples beyond the largest power of four, and returns a
+// reading with a resolution of 1/2^n of a raw value.
+func (f Filter) Apply(samples []uint16) float32 {
+	if len(samples) == 0 {
+		return 0
+	}
+	switch f {
+	case FilterAverage:
+		return adc_average(samples)
+	case FilterMedian:
+		return adc_median(samples)
+	case FilterDecimate:
+		n := uint(0)
+		for 1<<(2*(n+1)) <= len(samples) {
+			n++
+		}
+		return float32(adc_oversample(samples[:1<<(2*n)], n)) / float32(uint32(1)<<n)
+	default:
+		return float32(samples[0])
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Return the mean of a set of raw samples
+func adc_average(samples []uint16) float32 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sum := uint32(0)
+	for _, v := range samples {
+		sum += uint32(v)
+	}
+	return float32(sum) / float32(len(samples))
+}
+
+// Return the median of a set of raw samples, which are sorted in place. When
+// there is an even number of samples, the mean of the middle two is returned.
+func adc_median(samples []uint16) float32 {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	// Insertion sort, which is fast for the small number of samples involved
+	for i := 1; i < len(samples); i++ {
+		for j := i; j > 0 && samples[j] < samples[j-1]; j-- {
+			samples[j], samples[j-1] = samples[j-1], samples[j]
+		}
+	}
+
+	// Return the middle value
+	mid := len(samples) >> 1
+	if len(samples)&1 == 0 {
+		return (float32(samples[mid-1]) + float32(samples[mid])) / 2
+	}
+	return float32(samples[mid])
+}
+
+// Return a reading with n extra bits of resolution from 4^n raw samples, by
+// summing the samples and shifting right by n bits. The samples should include
+// some noise for the extra bits to be meaningful.
+func adc_oversample(samples []uint16, n uint) uint32 {
+	sum := uint32(0)
+	for _, v := range samples {
+		sum += uint32(v)
+	}
+	return sum >> n
+}
@@ -0,0 +1,89 @@
+package pico_test
+
+import (
+	"testing"
+
+	// Namespace import
+	. "github.com/djthorpe/go-pico"
+)
+
+// Sample sets with the shape of readings from the RP2040 ADC: a
+// potentiometer at mid-scale with noise of a few LSB, the same with
+// spikes from a switching regulator, and a slowly varying signal which
+// dithers between two codes
+var (
+	samples_noisy = []uint16{
+		2046, 2049, 2047, 2051, 2048, 2050, 2045, 2048, 2049, 2047, 2052, 2048, 2046, 2050, 2049, 2047,
+	}
+	samples_spikes = []uint16{
+		2047, 2049, 2048, 4095, 2048, 2046, 2050, 2048, 0, 2049, 2047, 2048, 2051, 2048, 3900, 2048,
+	}
+	samples_dither = []uint16{
+		1023, 1024, 1024, 1023, 1024, 1024, 1023, 1024, 1024, 1024, 1023, 1024, 1024, 1023, 1024, 1024,
+	}
+)
+
+func Test_Filter_001(t *testing.T) {
+	tests := []struct {
+		filter   Filter
+		samples  []uint16
+		expected float32
+	}{
+		{FilterNone, samples_noisy, 2046},
+		{FilterAverage, samples_noisy, 2048.25},
+		{FilterMedian, samples_noisy, 2048},
+		{FilterDecimate, samples_noisy, 2048.25},
+		{FilterAverage, samples_spikes, 2163.875},
+		{FilterMedian, samples_spikes, 2048},
+		{FilterAverage, samples_dither, 1023.6875},
+		{FilterDecimate, samples_dither, 1023.5},
+		{FilterDecimate, samples_dither[:4], 1023.5},
+		{FilterMedian, samples_dither, 1024},
+		{FilterAverage, nil, 0},
+	}
+	for _, test := range tests {
+		// Copy samples, as the median filter sorts in place
+		samples := append([]uint16{}, test.samples...)
+		if v := test.filter.Apply(samples); v != test.expected {
+			t.Errorf("filter %v: expected %v, got %v", test.filter, test.expected, v)
+		}
+	}
+}
+
+func Test_Filter_002(t *testing.T) {
+	// Median of an even number of samples is the mean of the middle two
+	if v := FilterMedian.Apply([]uint16{4, 1, 3, 2}); v != 2.5 {
+		t.Errorf("expected 2.5, got %v", v)
+	}
+	// Median sorts in place
+	samples := []uint16{3, 1, 2}
+	if v := FilterMedian.Apply(samples); v != 2 {
+		t.Errorf("expected 2, got %v", v)
+	}
+	for i := 1; i < len(samples); i++ {
+		if samples[i] < samples[i-1] {
+			t.Errorf("expected samples to be sorted: %v", samples)
+		}
+	}
+}
+
+func Test_Filter_003(t *testing.T) {
+	// Decimating 4^n samples gives n extra bits, with any samples beyond the
+	// largest power of four discarded
+	tests := []struct {
+		samples  []uint16
+		expected float32
+	}{
+		{samples_dither[:1], 1023},
+		{samples_dither[:3], 1023},
+		{samples_dither[:4], 1023.5},
+		{samples_dither[:15], 1023.5},
+		{samples_dither[:16], 1023.5},
+		{samples_noisy[:16], 2048.25},
+	}
+	for _, test := range tests {
+		if v := FilterDecimate.Apply(test.samples); v != test.expected {
+			t.Errorf("len=%v: expected %v, got %v", len(test.samples), test.expected, v)
+		}
+	}
+}
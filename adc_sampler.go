@@ -0,0 +1,162 @@
+//go:build pico
+
+package pico
+
+import (
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Sampler converts a set of ADC channels in round robin, using the
+// free-running mode of the ADC and the FIFO
+type Sampler struct {
+	ch   []uint32 // Channels in conversion order
+	mask uint32   // Round robin mask
+	rate uint32   // Samples per second on each channel
+	run  bool
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Return a sampler for one or more ADC channels, which samples each channel at
+// rate samples per second. When rate is zero, channels are converted
+// back-to-back at the maximum rate.
+func NewSampler(rate uint32, adc ...*ADC) (*Sampler, error) {
+	s := &Sampler{rate: rate}
+
+	// Check parameters
+	if err := assert(len(adc) > 0, ErrBadParameter.With("NewSampler")); err != nil {
+		return nil, err
+	}
+	if err := assert(rate == 0 || rate*uint32(len(adc)) >= ADC_MIN_SAMPLE_RATE, ErrBadParameter.With("NewSampler:", rate)); err != nil {
+		return nil, err
+	}
+	if err := assert(rate <= ADC_MAX_SAMPLE_RATE/uint32(len(adc)), ErrBadParameter.With("NewSampler:", rate)); err != nil {
+		return nil, err
+	}
+
+	// Round robin proceeds from the lowest to highest channel
+	for _, a := range adc {
+		if err := assert(a != nil && a.Num < NUM_ADC_CHANNELS, ErrBadParameter.With("NewSampler")); err != nil {
+			return nil, err
+		}
+		if err := assert(s.mask&(1<<a.Num) == 0, ErrDuplicateValue.With("NewSampler:", a.Num)); err != nil {
+			return nil, err
+		}
+		s.mask |= 1 << a.Num
+	}
+	for ch := uint32(0); ch < NUM_ADC_CHANNELS; ch++ {
+		if s.mask&(1<<ch) != 0 {
+			s.ch = append(s.ch, ch)
+		}
+	}
+
+	// Return success
+	return s, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the channels in the order they are returned by Read
+func (s *Sampler) Channels() []uint32 {
+	return s.ch
+}
+
+// Start free-running conversions. Any conversions already in the FIFO are
+// discarded.
+func (s *Sampler) Start() {
+	if s.run {
+		return
+	}
+
+	// Enable the temperature sensor if it is being sampled
+	if s.mask&(1<<ADC_temperature_input()) != 0 {
+		adc_temp_enable()
+	}
+
+	// Set the period between conversions across all channels
+	ADC_set_sample_rate(s.rate * uint32(len(s.ch)))
+
+	// Setup the FIFO with the error flag, and start from the first channel
+	// so that samples can be de-interleaved
+	ADC_fifo_setup(true, false, 1, true, false)
+	ADC_fifo_drain()
+	ADC_fifo_get_errors()
+	ADC_select_input(s.ch[0])
+	ADC_set_round_robin(s.mask)
+	ADC_run(true)
+	s.run = true
+}
+
+// Stop free-running conversions and drain the FIFO
+func (s *Sampler) Stop() {
+	if !s.run {
+		return
+	}
+	ADC_run(false)
+	ADC_fifo_drain()
+	ADC_set_round_robin(0)
+	ADC_fifo_setup(false, false, 0, false, false)
+	s.run = false
+}
+
+// Read samples into buf, which has one slice for each channel in the order
+// returned by Channels. Blocks until each slice is filled up to the length of
+// the shortest one, and returns the number of samples read per channel.
+//
+// ErrSampleSkipped is returned if the FIFO overflowed and samples were
+// dropped, in which case conversions are restarted from the first channel.
+// ErrUnexpectedValue is returned if any conversion reported an error.
+// In both cases the buffer is still filled.
+func (s *Sampler) Read(buf [][]uint16) (int, error) {
+	var result error
+
+	// Check parameters
+	if err := assert(len(buf) == len(s.ch), ErrBadParameter.With("Read")); err != nil {
+		return 0, err
+	}
+
+	// Determine number of samples per channel
+	n := len(buf[0])
+	for _, b := range buf {
+		if len(b) < n {
+			n = len(b)
+		}
+	}
+
+	// Start sampling
+	s.Start()
+
+	// Read samples from the FIFO, de-interleaving them into the buffer
+	for i := 0; i < n; {
+		for ch := 0; ch < len(s.ch); {
+			if over, _ := ADC_fifo_get_errors(); over {
+				// Samples were dropped, so restart from the first channel
+				result = ErrSampleSkipped.With("Read")
+				s.Stop()
+				s.Start()
+				ch = 0
+				continue
+			}
+			if ADC_fifo_is_empty() {
+				continue
+			}
+			value, err := ADC_fifo_get_with_err()
+			if err && result == nil {
+				result = ErrUnexpectedValue.With("Read:", s.ch[ch])
+			}
+			buf[ch][i] = value
+			ch++
+		}
+		i++
+	}
+
+	// Return number of samples per channel
+	return n, result
+}
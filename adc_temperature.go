@@ -0,0 +1,112 @@
+//go:build pico
+
+package pico
+
+import (
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Temperature reads the internal temperature sensor, which is connected to
+// the last ADC channel
+type Temperature struct {
+	adc *ADC
+	cal TemperatureCalibration
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	ADC_TEMP_SAMPLES = 16 // Default number of samples averaged for each reading
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Return the temperature sensor, which is enabled and has settled. Each
+// reading is the mean of ADC_TEMP_SAMPLES samples. Returns an error if the
+// ADC cannot be initialised.
+func NewTemperature() (*Temperature, error) {
+	adc, err := _GPIO.temp()
+	if err != nil {
+		return nil, err
+	}
+	t := &Temperature{adc: adc}
+	if err := t.adc.SetFilter(FilterAverage, ADC_TEMP_SAMPLES); err != nil {
+		return nil, err
+	}
+	adc_temp_enable()
+	return t, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the ADC channel connected to the sensor
+func (t *Temperature) ADC() *ADC {
+	return t.adc
+}
+
+// Set the number of samples averaged for each reading, which is between 1
+// and ADC_MAX_FILTER_SAMPLES
+func (t *Temperature) SetSamples(samples int) error {
+	return t.adc.SetFilter(FilterAverage, samples)
+}
+
+// Set the reference voltage of the ADC, or zero for ADC_DEFAULT_VREF
+func (t *Temperature) SetVref(vref float32) error {
+	if err := assert(vref >= 0, ErrBadParameter.With("SetVref:", vref)); err != nil {
+		return err
+	}
+	cal := t.adc.Calibration()
+	cal.Vref = vref
+	t.adc.SetCalibration(cal)
+	return nil
+}
+
+// Set the calibration of the sensor
+func (t *Temperature) SetCalibration(cal TemperatureCalibration) {
+	t.cal = cal
+}
+
+// Return the calibration of the sensor
+func (t *Temperature) Calibration() TemperatureCalibration {
+	return t.cal
+}
+
+// Return the voltage of the sensor
+func (t *Temperature) Voltage() float32 {
+	adc_temp_enable()
+	return t.adc.Voltage()
+}
+
+// Return the temperature in a unit
+func (t *Temperature) Get(unit TemperatureUnit) float32 {
+	return unit.Convert(t.cal.Celsius(t.Voltage()))
+}
+
+// Return the temperature in degrees Celsius
+func (t *Temperature) Celsius() float32 {
+	return t.Get(Celsius)
+}
+
+// Return the temperature in degrees Fahrenheit
+func (t *Temperature) Fahrenheit() float32 {
+	return t.Get(Fahrenheit)
+}
+
+// Return the temperature in Kelvin
+func (t *Temperature) Kelvin() float32 {
+	return t.Get(Kelvin)
+}
+
+// Disable the sensor, which saves power. It is enabled again by the next
+// reading, which waits for it to settle.
+func (t *Temperature) Disable() {
+	ADC_set_temp_sensor_enabled(false)
+}
@@ -0,0 +1,89 @@
+package pico
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// TemperatureUnit is a unit of temperature
+type TemperatureUnit uint8
+
+// TemperatureCalibration converts the voltage of the temperature sensor to
+// degrees Celsius. The zero value uses the typical values from section 4.9.5
+// of the RP2040 datasheet, which vary between devices by several degrees.
+type TemperatureCalibration struct {
+	V27    float32 // Sensor voltage at 27°C, or zero for ADC_TEMP_V27
+	Slope  float32 // Change in voltage per °C, or zero for ADC_TEMP_SLOPE
+	Offset float32 // Correction in °C added to each temperature
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	Celsius TemperatureUnit = iota
+	Fahrenheit
+	Kelvin
+)
+
+const (
+	ADC_TEMP_V27   = 0.706     // Typical sensor voltage at 27°C
+	ADC_TEMP_SLOPE = -0.001721 // Typical change in sensor voltage per °C
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Convert a temperature in degrees Celsius to the unit
+func (u TemperatureUnit) Convert(celsius float32) float32 {
+	switch u {
+	case Fahrenheit:
+		return celsius*9/5 + 32
+	case Kelvin:
+		return celsius + 273.15
+	default:
+		return celsius
+	}
+}
+
+// Return the temperature in degrees Celsius for a sensor voltage
+func (c TemperatureCalibration) Celsius(v float32) float32 {
+	return 27 + (v-c.v27())/c.slope() + c.Offset
+}
+
+// Return a copy of the calibration with the offset set from one sensor
+// voltage at a known temperature in degrees Celsius, keeping the existing
+// slope
+func (c TemperatureCalibration) CalibrateOffset(v, celsius float32) TemperatureCalibration {
+	c.Offset = 0
+	c.Offset = celsius - c.Celsius(v)
+	return c
+}
+
+// Return a copy of the calibration with the slope and offset set from sensor
+// voltages at two known temperatures in degrees Celsius, which should be far
+// apart
+func (c TemperatureCalibration) Calibrate(v1, celsius1, v2, celsius2 float32) TemperatureCalibration {
+	if celsius1 == celsius2 || v1 == v2 {
+		return c
+	}
+	c.Slope = (v2 - v1) / (celsius2 - celsius1)
+	c.V27 = v1 + (27-celsius1)*c.Slope
+	c.Offset = 0
+	return c
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (c TemperatureCalibration) v27() float32 {
+	if c.V27 == 0 {
+		return ADC_TEMP_V27
+	}
+	return c.V27
+}
+
+func (c TemperatureCalibration) slope() float32 {
+	if c.Slope == 0 {
+		return ADC_TEMP_SLOPE
+	}
+	return c.Slope
+}
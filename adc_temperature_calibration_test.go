@@ -0,0 +1,75 @@
+package pico_test
+
+import (
+	"math"
+	"testing"
+
+	// Namespace import
+	. "github.com/djthorpe/go-pico"
+)
+
+func Test_Temperature_001(t *testing.T) {
+	// Zero value uses the datasheet values
+	c := TemperatureCalibration{}
+	if v := c.Celsius(ADC_TEMP_V27); v != 27 {
+		t.Errorf("expected 27, got %v", v)
+	}
+	if v := c.Celsius(ADC_TEMP_V27 + 10*ADC_TEMP_SLOPE); math.Abs(float64(v-37)) > 1e-3 {
+		t.Errorf("expected 37, got %v", v)
+	}
+}
+
+func Test_Temperature_002(t *testing.T) {
+	// Convert units
+	tests := []struct {
+		unit     TemperatureUnit
+		celsius  float32
+		expected float32
+	}{
+		{Celsius, 25, 25},
+		{Fahrenheit, 0, 32},
+		{Fahrenheit, 100, 212},
+		{Fahrenheit, -40, -40},
+		{Kelvin, 0, 273.15},
+		{Kelvin, -273.15, 0},
+	}
+	for _, test := range tests {
+		if v := test.unit.Convert(test.celsius); math.Abs(float64(v-test.expected)) > 1e-3 {
+			t.Errorf("Convert(%v) = %v, expected %v", test.celsius, v, test.expected)
+		}
+	}
+}
+
+func Test_Temperature_003(t *testing.T) {
+	// One-point calibration of a sensor which reads 3°C low, as it reaches
+	// the datasheet voltage for 27°C only at 30°C
+	sensor := func(celsius float32) float32 {
+		return ADC_TEMP_V27 + (celsius-30)*ADC_TEMP_SLOPE
+	}
+	if v := (TemperatureCalibration{}).Celsius(sensor(30)); math.Abs(float64(v-27)) > 1e-2 {
+		t.Errorf("expected uncalibrated reading of 27, got %v", v)
+	}
+	c := TemperatureCalibration{}.CalibrateOffset(sensor(20), 20)
+	for _, celsius := range []float32{0, 20, 45, 80} {
+		if v := c.Celsius(sensor(celsius)); math.Abs(float64(v-celsius)) > 1e-2 {
+			t.Errorf("expected %v, got %v", celsius, v)
+		}
+	}
+}
+
+func Test_Temperature_004(t *testing.T) {
+	// Two-point calibration of a sensor with a different voltage at 27°C
+	// and a different slope
+	sensor := func(celsius float32) float32 {
+		return 0.712 + (celsius-27)*-0.00165
+	}
+	c := TemperatureCalibration{}.Calibrate(sensor(10), 10, sensor(60), 60)
+	for _, celsius := range []float32{-10, 10, 27, 45, 85} {
+		if v := c.Celsius(sensor(celsius)); math.Abs(float64(v-celsius)) > 1e-2 {
+			t.Errorf("expected %v, got %v", celsius, v)
+		}
+	}
+	if c.Offset != 0 {
+		t.Error("expected zero offset, got", c.Offset)
+	}
+}
@@ -8,6 +8,18 @@ var map_spi = map[Pin]SPI{
 	Pin(8): SPI{Num: 1, RX: Pin(8), TX: Pin(11), SCK: Pin(10), CS: Pin(9)},
 }
 
+// map_i2c maps from a GPIO pin to an I2C bus
+var map_i2c = map[Pin]I2C{
+	Pin(4): I2C{Num: 0, SDA: Pin(4), SCL: Pin(5)},
+	Pin(6): I2C{Num: 1, SDA: Pin(6), SCL: Pin(7)},
+}
+
+// map_uart maps from a GPIO pin to a UART
+var map_uart = map[Pin]UART{
+	Pin(0): UART{Num: 0, TX: Pin(0), RX: Pin(1), CTS: Pin(2), RTS: Pin(3)},
+	Pin(4): UART{Num: 1, TX: Pin(4), RX: Pin(5), CTS: Pin(6), RTS: Pin(7)},
+}
+
 // map_adc maps from a GPIO pin to an ADC channel
 var map_adc = map[Pin]ADC{
 	Pin(26): ADC{Num: 0},
@@ -0,0 +1,5 @@
+/*
+I2CScan: Scan the I2C bus on GP4 (SDA) and GP5 (SCL) for devices, and print
+an i2cdetect-style grid of the addresses which respond. Outputs to the console.
+*/
+package main
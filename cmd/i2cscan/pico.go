@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	// Module imports
+	sdk "github.com/djthorpe/go-pico/pkg/sdk"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico"
+)
+
+// Define the pins used
+var (
+	SDA = Pin(4) // SDA pin, with SCL on the next pin
+)
+
+// Main function
+func main() {
+	// Wait for the console to connect
+	time.Sleep(2 * time.Second)
+
+	i2c := SDA.I2C()
+	if i2c == nil {
+		fmt.Println("No I2C bus on", SDA)
+		return
+	}
+
+	// Scan the bus
+	found, err := i2c.Scan()
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	// Print the grid, with reserved addresses left blank
+	present := make(map[uint8]bool, len(found))
+	for _, addr := range found {
+		present[addr] = true
+	}
+	fmt.Println("     0  1  2  3  4  5  6  7  8  9  a  b  c  d  e  f")
+	for row := uint8(0); row < 0x80; row += 0x10 {
+		fmt.Printf("%02x:", row)
+		for addr := row; addr < row+0x10; addr++ {
+			switch {
+			case sdk.I2C_reserved_addr(addr):
+				fmt.Print("   ")
+			case present[addr]:
+				fmt.Printf(" %02x", addr)
+			default:
+				fmt.Print(" --")
+			}
+		}
+		fmt.Println()
+	}
+	fmt.Println(len(found), "device(s) found")
+}
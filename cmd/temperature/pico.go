@@ -10,23 +10,28 @@ import (
 // Define the pins used
 var (
 	BUTTON = Pin(23) // BOOTSEL button on the Pico Lipo
-	TEMP   = GPIO.Temperature()
 )
 
 // Main function
 func main() {
-	CH := make(chan uint16, 10)
+	CH := make(chan struct{}, 10)
+
+	temp, err := NewTemperature()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
 	BUTTON.SetMode(ModeInput)
 	BUTTON.SetInterrupt(func(p Pin, s State) {
 		if s == StateFall {
-			CH <- TEMP.Get()
+			CH <- struct{}{}
 		}
 	})
 
 	// Wait forever
-	for evt := range CH {
-		fmt.Println(evt)
+	for range CH {
+		fmt.Printf("%.1f°C\n", temp.Celsius())
 	}
 }
 
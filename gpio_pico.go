@@ -198,9 +198,8 @@ func (g *gpio) adc(pin Pin) (*ADC, error) {
 	}
 
 	// Initialise ADC device
-	if !g.adcinit {
-		ADC_init()
-		g.adcinit = true
+	if err := g.adc_init(); err != nil {
+		return nil, err
 	}
 
 	// Get ADC device
@@ -228,19 +227,31 @@ func (g *gpio) adc(pin Pin) (*ADC, error) {
 }
 
 // Return ADC device linked to temperature sensor
-func (g *gpio) temp() *ADC {
+func (g *gpio) temp() (*ADC, error) {
 	// Initialise ADC device
-	if !g.adcinit {
-		ADC_init()
-		g.adcinit = true
+	if err := g.adc_init(); err != nil {
+		return nil, err
 	}
 
 	// Return the ADC
-	return &ADC{Num: ADC_temperature_input()}
+	return &ADC{Num: ADC_temperature_input()}, nil
 }
 
-// Return SPI device on a pin
-func (g *gpio) spi(pin Pin) (*SPI, error) {
+// Initialise the ADC once
+func (g *gpio) adc_init() error {
+	if g.adcinit {
+		return nil
+	}
+	if err := ADC_init(); err != nil {
+		return err
+	}
+	g.adcinit = true
+	return nil
+}
+
+// Return SPI device on a pin. The chip select pin is set as an output when cs
+// is true, otherwise only the SCK, TX and RX pins are set.
+func (g *gpio) spi(pin Pin, cs bool) (*SPI, error) {
 	// Check parameters
 	if err := assert(pin < NUM_BANK0_GPIOS, ErrBadParameter.With(pin)); err != nil {
 		return nil, err
@@ -261,15 +272,61 @@ func (g *gpio) spi(pin Pin) (*SPI, error) {
 		return nil, err
 	}
 	// Set chip select pin
-	if err := g.setmode(spi.CS, ModeOutput); err != nil {
-		return nil, err
-	} else if err := g.set(spi.CS, true); err != nil {
-		return nil, err
+	if cs {
+		if err := g.setmode(spi.CS, ModeOutput); err != nil {
+			return nil, err
+		} else if err := g.set(spi.CS, true); err != nil {
+			return nil, err
+		}
 	}
 	// Initalize SPI device
 	return _NewSPI(spi), nil
 }
 
+// Return I2C bus on a pin
+func (g *gpio) i2c(pin Pin) (*I2C, error) {
+	// Check parameters
+	if err := assert(pin < NUM_BANK0_GPIOS, ErrBadParameter.With(pin)); err != nil {
+		return nil, err
+	}
+	// Get I2C bus
+	i2c, exists := map_i2c[pin]
+	if !exists {
+		return nil, ErrBadParameter.With(pin)
+	}
+	// Set mode
+	if err := g.setmode(i2c.SDA, ModeI2C); err != nil {
+		return nil, err
+	}
+	if err := g.setmode(i2c.SCL, ModeI2C); err != nil {
+		return nil, err
+	}
+	// Initalize I2C bus
+	return _NewI2C(i2c)
+}
+
+// Return UART on a pin
+func (g *gpio) uart(pin Pin) (*UART, error) {
+	// Check parameters
+	if err := assert(pin < NUM_BANK0_GPIOS, ErrBadParameter.With(pin)); err != nil {
+		return nil, err
+	}
+	// Get UART
+	uart, exists := map_uart[pin]
+	if !exists {
+		return nil, ErrBadParameter.With(pin)
+	}
+	// Set mode
+	if err := g.setmode(uart.TX, ModeUART); err != nil {
+		return nil, err
+	}
+	if err := g.setmode(uart.RX, ModeUART); err != nil {
+		return nil, err
+	}
+	// Initalize UART
+	return _NewUART(uart)
+}
+
 // Add pin handler
 func (g *gpio) setInterrupt(pin Pin, handler func(pin Pin, state State)) error {
 	if handler != nil {
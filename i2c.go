@@ -0,0 +1,65 @@
+package pico
+
+import (
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// I2CSpeed is the bus speed, in bits per second
+type I2CSpeed uint32
+
+// I2CAddrMode determines whether devices have 7-bit or 10-bit addresses
+type I2CAddrMode uint8
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	I2CStandard I2CSpeed = I2C_STANDARD_MODE  // Standard mode, 100 kHz
+	I2CFast     I2CSpeed = I2C_FAST_MODE      // Fast mode, 400 kHz
+	I2CFastPlus I2CSpeed = I2C_FAST_MODE_PLUS // Fast mode plus, 1 MHz
+)
+
+const (
+	I2CAddr7Bit I2CAddrMode = iota
+	I2CAddr10Bit
+)
+
+const (
+	I2C_MAX_ADDR       = 0x7F  // Maximum 7-bit address
+	I2C_MAX_ADDR_10BIT = 0x3FF // Maximum 10-bit address
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the SCL timing for the speed, given the clk_sys frequency. Returns
+// ErrBadParameter if the speed is not supported, or cannot be achieved at
+// that frequency.
+func (s I2CSpeed) Timing(freq uint32) (I2C_timing_t, error) {
+	if err := assert(s == I2CStandard || s == I2CFast || s == I2CFastPlus, ErrBadParameter.With("I2CSpeed:", uint32(s))); err != nil {
+		return I2C_timing_t{}, err
+	}
+	timing, ok := I2C_baudrate_solve(freq, uint32(s))
+	if !ok {
+		return timing, ErrBadParameter.With("I2CSpeed:", uint32(s), " at ", freq, "Hz")
+	}
+	return timing, nil
+}
+
+// Return true if an address is valid for the address mode. 7-bit addresses
+// which are reserved are not valid.
+func (m I2CAddrMode) Valid(addr uint16) bool {
+	switch m {
+	case I2CAddr7Bit:
+		return addr <= I2C_MAX_ADDR && !I2C_reserved_addr(uint8(addr))
+	case I2CAddr10Bit:
+		return addr <= I2C_MAX_ADDR_10BIT
+	default:
+		return false
+	}
+}
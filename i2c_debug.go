@@ -0,0 +1,32 @@
+//go:build debug
+
+package pico
+
+import "fmt"
+
+//////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (v *I2C) String() string {
+	str := "<i2c"
+	str += fmt.Sprint(" num=", v.Num)
+	if v.Baud > 0 {
+		str += fmt.Sprint(" baud=", v.Baud)
+	}
+	str += fmt.Sprint(" sda=", v.SDA)
+	str += fmt.Sprint(" scl=", v.SCL)
+	if v.mode == I2CAddr10Bit {
+		str += " 10bit"
+	}
+	str += fmt.Sprint(" timeout=", v.Timeout)
+	return str + ">"
+}
+
+func (v *I2CSlave) String() string {
+	str := "<i2cslave"
+	str += fmt.Sprint(" num=", v.Num)
+	str += fmt.Sprint(" sda=", v.SDA)
+	str += fmt.Sprint(" scl=", v.SCL)
+	str += fmt.Sprintf(" addr=0x%02X", v.Addr)
+	return str + ">"
+}
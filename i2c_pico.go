@@ -0,0 +1,206 @@
+//go:build pico
+
+package pico
+
+import (
+	"errors"
+	"time"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// I2C represents an Inter-Integrated Circuit bus in master mode. It satisfies
+// the drivers.I2C interface, so it can be used with TinyGo driver packages.
+// Each transfer fails if it does not complete within the timeout.
+type I2C struct {
+	Num     uint32
+	SDA     Pin
+	SCL     Pin
+	Baud    uint32 // Achieved baud rate
+	Timeout time.Duration
+	speed   I2CSpeed
+	mode    I2CAddrMode
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	I2C_DEFAULT_SPEED   = I2CStandard
+	I2C_DEFAULT_TIMEOUT = 100 * time.Millisecond
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func _NewI2C(config I2C) (*I2C, error) {
+	// Check the pins are an SDA and SCL pair on the instance
+	if inst, ok := I2C_gpio_to_inst(GPIO_pin(config.SDA), GPIO_pin(config.SCL)); !ok || inst != config.Num {
+		return nil, ErrBadParameter.With("I2C:", config.SDA, ",", config.SCL)
+	}
+
+	// Set defaults
+	if config.Timeout == 0 {
+		config.Timeout = I2C_DEFAULT_TIMEOUT
+	}
+
+	// Initialise I2C, and set the speed and address mode
+	i := &config
+	I2C_init(i.Num, uint32(I2C_DEFAULT_SPEED))
+	if err := i.SetConfig(I2C_DEFAULT_SPEED, I2CAddr7Bit); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return i, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set the bus speed and address mode. Fast mode plus also sets the drive
+// strength of the pins to 12mA.
+func (i *I2C) SetConfig(speed I2CSpeed, mode I2CAddrMode) error {
+	if err := assert(mode <= I2CAddr10Bit, ErrBadParameter.With("SetConfig:", mode)); err != nil {
+		return err
+	}
+	if _, err := speed.Timing(CLOCK_get_hz(CLOCK_sys)); err != nil {
+		return err
+	}
+
+	// Set pad drive strength
+	drive := GPIO_DRIVE_STRENGTH_4MA
+	if speed == I2CFastPlus {
+		drive = GPIO_DRIVE_STRENGTH_12MA
+	}
+	GPIO_set_drive_strength(GPIO_pin(i.SDA), drive)
+	GPIO_set_drive_strength(GPIO_pin(i.SCL), drive)
+
+	// Set baud rate and address mode
+	i.Baud = I2C_set_baudrate(i.Num, uint32(speed))
+	I2C_set_addr_mode(i.Num, mode == I2CAddr10Bit)
+	i.speed, i.mode = speed, mode
+
+	// Return success
+	return nil
+}
+
+// Return the bus speed and address mode
+func (i *I2C) Config() (I2CSpeed, I2CAddrMode) {
+	return i.speed, i.mode
+}
+
+// Write w to a device and then read into r, with a repeated start between
+// them. Either can be empty, in which case only a write or read is made.
+func (i *I2C) Tx(addr uint16, w, r []byte) error {
+	if err := assert(i.mode.Valid(addr), ErrBadParameter.With("Tx:", addr)); err != nil {
+		return err
+	}
+	if len(w) > 0 {
+		if err := i.write(addr, w, len(r) > 0); err != nil {
+			return err
+		}
+	}
+	if len(r) > 0 {
+		if err := i.read(addr, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read a block of data from consecutive registers, starting at reg
+func (i *I2C) ReadRegister(addr uint8, reg uint8, data []byte) error {
+	w := [1]byte{reg}
+	return i.Tx(uint16(addr), w[:], data)
+}
+
+// Write a block of data to consecutive registers, starting at reg
+func (i *I2C) WriteRegister(addr uint8, reg uint8, data []byte) error {
+	w := make([]byte, len(data)+1)
+	w[0] = reg
+	copy(w[1:], data)
+	return i.Tx(uint16(addr), w, nil)
+}
+
+// Read an 8-bit register
+func (i *I2C) ReadRegister_Uint8(addr, reg uint8) (uint8, error) {
+	var r [1]byte
+	if err := i.ReadRegister(addr, reg, r[:]); err != nil {
+		return 0, err
+	}
+	return r[0], nil
+}
+
+// Write an 8-bit register
+func (i *I2C) WriteRegister_Uint8(addr, reg, v uint8) error {
+	w := [2]byte{reg, v}
+	return i.Tx(uint16(addr), w[:], nil)
+}
+
+// Read a 16-bit register, which is sent most significant byte first
+func (i *I2C) ReadRegister_Uint16(addr, reg uint8) (uint16, error) {
+	var r [2]byte
+	if err := i.ReadRegister(addr, reg, r[:]); err != nil {
+		return 0, err
+	}
+	return uint16(r[0])<<8 | uint16(r[1]), nil
+}
+
+// Write a 16-bit register, which is sent most significant byte first
+func (i *I2C) WriteRegister_Uint16(addr, reg uint8, v uint16) error {
+	w := [3]byte{reg, uint8(v >> 8), uint8(v)}
+	return i.Tx(uint16(addr), w[:], nil)
+}
+
+// Return the 7-bit addresses of devices on the bus, in ascending order. Each
+// address which is not reserved is probed by reading a byte, and a device
+// is present if it acknowledges. Returns an error if the bus fails for
+// another reason, for example if SDA or SCL are held low. Returns
+// ErrNotImplemented for 10-bit addresses.
+func (i *I2C) Scan() ([]uint8, error) {
+	if err := assert(i.mode == I2CAddr7Bit, ErrNotImplemented.With("Scan: 10-bit addresses")); err != nil {
+		return nil, err
+	}
+	var result []uint8
+	var buf [1]byte
+	for addr := uint8(0); addr <= I2C_MAX_ADDR; addr++ {
+		if !I2CAddr7Bit.Valid(uint16(addr)) {
+			continue
+		}
+		if err := i.read(uint16(addr), buf[:]); err == nil {
+			result = append(result, addr)
+		} else if !errors.Is(err, ErrAddrNack) {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Write bytes, retaining the bus if nostop is true
+func (i *I2C) write(addr uint16, w []byte, nostop bool) error {
+	if n, err := I2C_write_timeout(i.Num, addr, w, nostop, i.Timeout); err != nil {
+		return err
+	} else if n != len(w) {
+		return ErrUnexpectedValue.With("Tx: wrote ", n, " of ", len(w), " bytes")
+	}
+	return nil
+}
+
+// Read bytes, releasing the bus at the end
+func (i *I2C) read(addr uint16, r []byte) error {
+	if n, err := I2C_read_timeout(i.Num, addr, r, false, i.Timeout); err != nil {
+		return err
+	} else if n != len(r) {
+		return ErrUnexpectedValue.With("Tx: read ", n, " of ", len(r), " bytes")
+	}
+	return nil
+}
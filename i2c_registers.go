@@ -0,0 +1,117 @@
+package pico
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// I2CSlaveHandler responds to a controller when the RP2040 is an I2C slave.
+// The methods are called from an interrupt handler, so should return quickly.
+type I2CSlaveHandler interface {
+	// Receive is called for each byte written by the controller
+	Receive(byte)
+
+	// Request is called for each byte read by the controller, and returns
+	// the byte to send
+	Request() byte
+
+	// Finish is called at the end of a transfer, on a STOP or RESTART
+	Finish()
+}
+
+// I2CRegisters is an I2CSlaveHandler which emulates a device with byte
+// registers. The first byte written in a transfer sets the register index,
+// and further bytes are written to consecutive registers. Reads are from
+// consecutive registers, starting at the index. The index wraps at the
+// number of registers.
+type I2CRegisters struct {
+	mem      []byte
+	index    int
+	start    int  // Register index at the start of a write
+	written  int  // Number of registers written in this transfer
+	indexed  bool // True when the register index has been received
+	callback I2CRegisters_callback_t
+}
+
+// I2CRegisters_callback_t is called at the end of a transfer in which the
+// controller wrote n registers, starting at reg
+type I2CRegisters_callback_t func(r *I2CRegisters, reg uint8, n int)
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	I2C_MAX_REGISTERS = 256 // Maximum number of registers, as the index is a byte
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Create registers, which are initially zero. Size is between 1 and
+// I2C_MAX_REGISTERS.
+func NewI2CRegisters(size int) *I2CRegisters {
+	if size < 1 || size > I2C_MAX_REGISTERS {
+		return nil
+	}
+	return &I2CRegisters{mem: make([]byte, size)}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the number of registers
+func (r *I2CRegisters) Len() int {
+	return len(r.mem)
+}
+
+// Return the value of a register
+func (r *I2CRegisters) Get(reg uint8) byte {
+	return r.mem[int(reg)%len(r.mem)]
+}
+
+// Set the value of a register
+func (r *I2CRegisters) Set(reg uint8, v byte) {
+	r.mem[int(reg)%len(r.mem)] = v
+}
+
+// Set a callback, which is called when the controller has written registers.
+// If called with nil then the callback is disabled.
+func (r *I2CRegisters) SetInterrupt(callback I2CRegisters_callback_t) {
+	r.callback = callback
+}
+
+// Receive a byte from the controller, which is the register index at the
+// start of a transfer and otherwise the value of the next register
+func (r *I2CRegisters) Receive(v byte) {
+	if !r.indexed {
+		r.index = int(v) % len(r.mem)
+		r.start = r.index
+		r.indexed = true
+		return
+	}
+	r.mem[r.index] = v
+	r.next()
+	r.written++
+}
+
+// Return the value of the next register to the controller
+func (r *I2CRegisters) Request() byte {
+	v := r.mem[r.index]
+	r.next()
+	return v
+}
+
+// End a transfer. The index is retained, so a read without an index
+// continues from the last register.
+func (r *I2CRegisters) Finish() {
+	start, written := r.start, r.written
+	r.indexed, r.written = false, 0
+	if written > 0 && r.callback != nil {
+		r.callback(r, uint8(start), written)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (r *I2CRegisters) next() {
+	r.index = (r.index + 1) % len(r.mem)
+}
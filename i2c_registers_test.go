@@ -0,0 +1,102 @@
+package pico_test
+
+import (
+	"testing"
+
+	// Namespace import
+	. "github.com/djthorpe/go-pico"
+)
+
+// write emulates a controller writing bytes in a transfer
+func write(h I2CSlaveHandler, data ...byte) {
+	for _, v := range data {
+		h.Receive(v)
+	}
+	h.Finish()
+}
+
+// read emulates a controller reading n bytes in a transfer
+func read(h I2CSlaveHandler, n int) []byte {
+	r := make([]byte, n)
+	for i := range r {
+		r[i] = h.Request()
+	}
+	h.Finish()
+	return r
+}
+
+func Test_I2CRegisters_001(t *testing.T) {
+	// Size must be between 1 and I2C_MAX_REGISTERS
+	if NewI2CRegisters(0) != nil {
+		t.Error("Expected nil for size 0")
+	}
+	if NewI2CRegisters(I2C_MAX_REGISTERS+1) != nil {
+		t.Error("Expected nil for size", I2C_MAX_REGISTERS+1)
+	}
+	if r := NewI2CRegisters(I2C_MAX_REGISTERS); r == nil || r.Len() != I2C_MAX_REGISTERS {
+		t.Error("Unexpected registers", r)
+	}
+}
+
+func Test_I2CRegisters_002(t *testing.T) {
+	// Write registers, then set the index and read them back
+	r := NewI2CRegisters(16)
+	write(r, 0x04, 0x11, 0x22, 0x33)
+	if r.Get(4) != 0x11 || r.Get(5) != 0x22 || r.Get(6) != 0x33 {
+		t.Error("Unexpected registers")
+	}
+	write(r, 0x05)
+	if data := read(r, 2); data[0] != 0x22 || data[1] != 0x33 {
+		t.Error("Unexpected read", data)
+	}
+	// A read without an index continues from the last register
+	if data := read(r, 1); data[0] != 0 {
+		t.Error("Unexpected read", data)
+	}
+	if data := read(r, 1); data[0] != 0 || r.Get(8) != 0 {
+		t.Error("Unexpected read", data)
+	}
+}
+
+func Test_I2CRegisters_003(t *testing.T) {
+	// Write with a repeated start before the read
+	r := NewI2CRegisters(4)
+	r.Set(2, 0xAB)
+	r.Set(3, 0xCD)
+	r.Receive(0x02)
+	r.Finish()
+	if data := read(r, 3); data[0] != 0xAB || data[1] != 0xCD || data[2] != 0x00 {
+		t.Error("Unexpected read, expected wrap at end", data)
+	}
+}
+
+func Test_I2CRegisters_004(t *testing.T) {
+	// The index and writes wrap at the number of registers
+	r := NewI2CRegisters(4)
+	write(r, 0x06, 0x01, 0x02, 0x03)
+	if r.Get(2) != 0x01 || r.Get(3) != 0x02 || r.Get(0) != 0x03 {
+		t.Error("Unexpected registers")
+	}
+}
+
+func Test_I2CRegisters_005(t *testing.T) {
+	// The callback is called after registers have been written
+	r := NewI2CRegisters(8)
+	var reg uint8
+	var n int
+	r.SetInterrupt(func(_ *I2CRegisters, r uint8, count int) {
+		reg, n = r, count
+	})
+	write(r, 0x01)
+	if n != 0 {
+		t.Error("Unexpected callback for index only")
+	}
+	write(r, 0x03, 0xFF, 0xFE)
+	if reg != 3 || n != 2 {
+		t.Error("Unexpected callback", reg, n)
+	}
+	read(r, 2)
+	if reg != 3 || n != 2 {
+		t.Error("Unexpected callback after read")
+	}
+}
@@ -0,0 +1,161 @@
+//go:build pico
+
+package pico
+
+import (
+	// Module imports
+	rp "device/rp"
+	interrupt "runtime/interrupt"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// I2CSlave is an I2C interface in peripheral (target) mode, which responds
+// to a controller on a 7-bit address. Bytes written and read by the
+// controller are passed to a handler from an interrupt handler.
+type I2CSlave struct {
+	Num     uint32
+	SDA     Pin
+	SCL     Pin
+	Addr    uint8
+	handler I2CSlaveHandler
+	active  bool // True when a transfer is in progress
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	i2c_slave      [NUM_I2CS]*I2CSlave
+	i2c_slave_intr = [NUM_I2CS]interrupt.Interrupt{
+		interrupt.New(rp.IRQ_I2C0_IRQ, i2c0_intr_handler),
+		interrupt.New(rp.IRQ_I2C1_IRQ, i2c1_intr_handler),
+	}
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Return an I2C interface in peripheral mode for the SDA pin, as for
+// Pin.I2C, which responds on a 7-bit address. The handler is called
+// from an interrupt handler; use I2CRegisters to emulate a device with
+// byte registers.
+func NewI2CSlave(pin Pin, addr uint8, handler I2CSlaveHandler) (*I2CSlave, error) {
+	// Check parameters
+	config, exists := map_i2c[pin]
+	if !exists {
+		return nil, ErrBadParameter.With("NewI2CSlave:", pin)
+	}
+	if err := assert(I2CAddr7Bit.Valid(uint16(addr)), ErrBadParameter.With("NewI2CSlave:", addr)); err != nil {
+		return nil, err
+	}
+	if err := assert(handler != nil, ErrBadParameter.With("NewI2CSlave")); err != nil {
+		return nil, err
+	}
+	if err := assert(i2c_slave[config.Num] == nil, ErrDuplicateValue.With("NewI2CSlave:", pin)); err != nil {
+		return nil, err
+	}
+
+	// Set pins
+	for _, pin := range []Pin{config.SDA, config.SCL} {
+		if err := pin.SetMode(ModeI2C); err != nil {
+			return nil, err
+		}
+	}
+
+	// Initialise the interface in peripheral mode. The baud rate sets the
+	// spike suppression and hold time, the controller drives the clock
+	s := &I2CSlave{
+		Num:     config.Num,
+		SDA:     config.SDA,
+		SCL:     config.SCL,
+		Addr:    addr,
+		handler: handler,
+	}
+	I2C_init(s.Num, uint32(I2C_DEFAULT_SPEED))
+	I2C_set_slave_mode(s.Num, true, uint16(addr))
+
+	// Enable interrupts
+	i2c_slave[s.Num] = s
+	I2C_clear_irq(s.Num, I2C_IRQ_RD_REQ|I2C_IRQ_TX_ABRT|I2C_IRQ_STOP_DET|I2C_IRQ_START_DET)
+	I2C_set_irq_mask(s.Num, I2C_IRQ_RX_FULL|I2C_IRQ_RD_REQ|I2C_IRQ_TX_ABRT|I2C_IRQ_STOP_DET|I2C_IRQ_START_DET)
+	i2c_slave_intr[s.Num].Enable()
+
+	// Return success
+	return s, nil
+}
+
+// Disable the interface
+func (s *I2CSlave) Close() error {
+	i2c_slave_intr[s.Num].Disable()
+	I2C_set_irq_mask(s.Num, 0)
+	I2C_deinit(s.Num)
+	i2c_slave[s.Num] = nil
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// End a transfer, if one is in progress
+func (s *I2CSlave) finish() {
+	if s.active {
+		s.handler.Finish()
+		s.active = false
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS - INTERRUPTS
+
+func i2c0_intr_handler(interrupt.Interrupt) {
+	i2c_slave_intr_handler(i2c_slave[0])
+}
+
+func i2c1_intr_handler(interrupt.Interrupt) {
+	i2c_slave_intr_handler(i2c_slave[1])
+}
+
+// Interrupt handler, which passes received bytes to the handler, requests
+// bytes from the handler when the controller reads, and ends a transfer on
+// START, STOP or abort
+func i2c_slave_intr_handler(s *I2CSlave) {
+	if s == nil {
+		return
+	}
+	status := I2C_get_irq_status(s.Num)
+
+	// A new transfer has started, or the transfer was aborted. A RESTART is
+	// detected as a START.
+	if status&(I2C_IRQ_TX_ABRT|I2C_IRQ_START_DET) != 0 {
+		I2C_clear_irq(s.Num, status&(I2C_IRQ_TX_ABRT|I2C_IRQ_START_DET))
+		s.finish()
+	}
+
+	// Drain the RX FIFO
+	if status&I2C_IRQ_RX_FULL != 0 {
+		s.active = true
+		for I2C_get_read_available(s.Num) > 0 {
+			s.handler.Receive(I2C_get_data(s.Num))
+		}
+	}
+
+	// The transfer has ended, after any bytes received have been passed to
+	// the handler
+	if status&I2C_IRQ_STOP_DET != 0 {
+		I2C_clear_irq(s.Num, I2C_IRQ_STOP_DET)
+		s.finish()
+	}
+
+	// Send a byte to the controller
+	if status&I2C_IRQ_RD_REQ != 0 {
+		I2C_clear_irq(s.Num, I2C_IRQ_RD_REQ)
+		s.active = true
+		I2C_put_data(s.Num, s.handler.Request())
+	}
+}
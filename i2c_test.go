@@ -0,0 +1,70 @@
+package pico_test
+
+import (
+	"errors"
+	"testing"
+
+	// Namespace import
+	. "github.com/djthorpe/go-pico"
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+func Test_I2C_001(t *testing.T) {
+	// Addresses which are valid for each address mode
+	tests := []struct {
+		mode  I2CAddrMode
+		addr  uint16
+		valid bool
+	}{
+		{I2CAddr7Bit, 0x00, false},
+		{I2CAddr7Bit, 0x07, false},
+		{I2CAddr7Bit, 0x08, true},
+		{I2CAddr7Bit, 0x76, true},
+		{I2CAddr7Bit, 0x77, true},
+		{I2CAddr7Bit, 0x78, false},
+		{I2CAddr7Bit, 0x80, false},
+		{I2CAddr7Bit, 0x3FF, false},
+		{I2CAddr10Bit, 0x00, true},
+		{I2CAddr10Bit, 0x78, true},
+		{I2CAddr10Bit, 0x3FF, true},
+		{I2CAddr10Bit, 0x400, false},
+		{I2CAddrMode(2), 0x76, false},
+	}
+	for _, test := range tests {
+		if valid := test.mode.Valid(test.addr); valid != test.valid {
+			t.Errorf("I2CAddrMode(%d).Valid(0x%X) = %v, expected %v", test.mode, test.addr, valid, test.valid)
+		}
+	}
+}
+
+func Test_I2C_002(t *testing.T) {
+	// Each speed at the default and a low clk_sys frequency
+	tests := []struct {
+		speed I2CSpeed
+		freq  uint32
+		baud  uint32
+		err   error
+	}{
+		{I2CStandard, 125_000_000, 100_000, nil},
+		{I2CFast, 125_000_000, 399_361, nil},
+		{I2CFastPlus, 125_000_000, 1_000_000, nil},
+		{I2CStandard, 12_000_000, 100_000, nil},
+		{I2CFast, 12_000_000, 400_000, nil},
+		{I2CFastPlus, 12_000_000, 0, ErrBadParameter},
+		{I2CSpeed(200_000), 125_000_000, 0, ErrBadParameter},
+		{I2CSpeed(3_400_000), 125_000_000, 0, ErrBadParameter},
+		{I2CSpeed(0), 125_000_000, 0, ErrBadParameter},
+	}
+	for _, test := range tests {
+		timing, err := test.speed.Timing(test.freq)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Errorf("I2CSpeed(%d).Timing(%d) returned %v, expected %v", test.speed, test.freq, err, test.err)
+			}
+		} else if err != nil {
+			t.Errorf("I2CSpeed(%d).Timing(%d) returned %v", test.speed, test.freq, err)
+		} else if timing.Baudrate != test.baud {
+			t.Errorf("I2CSpeed(%d).Timing(%d) = %d, expected %d", test.speed, test.freq, timing.Baudrate, test.baud)
+		}
+	}
+}
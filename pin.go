@@ -1,3 +1,5 @@
+//go:build pico
+
 package pico
 
 //////////////////////////////////////////////////////////////////////////////
@@ -54,13 +56,31 @@ func (p Pin) ADC() *ADC {
 
 // Get SPI for pin
 func (p Pin) SPI() *SPI {
-	if spi, err := _GPIO.spi(p); err != nil {
+	if spi, err := _GPIO.spi(p, true); err != nil {
 		return nil
 	} else {
 		return spi
 	}
 }
 
+// Get I2C for pin
+func (p Pin) I2C() *I2C {
+	if i2c, err := _GPIO.i2c(p); err != nil {
+		return nil
+	} else {
+		return i2c
+	}
+}
+
+// Get UART for pin
+func (p Pin) UART() *UART {
+	if uart, err := _GPIO.uart(p); err != nil {
+		return nil
+	} else {
+		return uart
+	}
+}
+
 // Set pin interrupt
 func (p Pin) SetInterrupt(callback Pin_callback_t) {
 	_GPIO.setInterrupt(p, callback)
@@ -19,6 +19,12 @@ const (
 	ErrTimeout
 	ErrNotImplemented
 	ErrNotInitialised
+	ErrOverflow
+	ErrUnderflow
+	ErrAddrNack
+	ErrDataNack
+	ErrArbitrationLost
+	ErrAborted
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -40,6 +46,18 @@ func (e Error) Error() string {
 		return "ErrNotImplemented"
 	case ErrNotInitialised:
 		return "ErrNotInitialised"
+	case ErrOverflow:
+		return "ErrOverflow"
+	case ErrUnderflow:
+		return "ErrUnderflow"
+	case ErrAddrNack:
+		return "ErrAddrNack"
+	case ErrDataNack:
+		return "ErrDataNack"
+	case ErrArbitrationLost:
+		return "ErrArbitrationLost"
+	case ErrAborted:
+		return "ErrAborted"
 	default:
 		return "Undefined error"
 	}
@@ -0,0 +1,195 @@
+package modbus
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Client sends requests to servers and reads their responses
+type Client struct {
+	port
+	Timeout time.Duration // Time to wait for a response
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DefaultTimeout = time.Second
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Create a client which reads and writes frames at a baud rate
+func NewClient(rw io.ReadWriter, baud uint32) *Client {
+	return &Client{port: port{rw: rw, baud: baud}, Timeout: DefaultTimeout}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Read count coils from a server, starting at address start
+func (c *Client) ReadCoils(addr uint8, start, count uint16) ([]bool, error) {
+	return c.readBits(addr, FuncReadCoils, start, count)
+}
+
+// Read count discrete inputs from a server, starting at address start
+func (c *Client) ReadDiscreteInputs(addr uint8, start, count uint16) ([]bool, error) {
+	return c.readBits(addr, FuncReadDiscreteInputs, start, count)
+}
+
+// Read count holding registers from a server, starting at address start
+func (c *Client) ReadHoldingRegisters(addr uint8, start, count uint16) ([]uint16, error) {
+	return c.readRegisters(addr, FuncReadHoldingRegisters, start, count)
+}
+
+// Read count input registers from a server, starting at address start
+func (c *Client) ReadInputRegisters(addr uint8, start, count uint16) ([]uint16, error) {
+	return c.readRegisters(addr, FuncReadInputRegisters, start, count)
+}
+
+// Write a coil on a server, or all servers if addr is AddrBroadcast
+func (c *Client) WriteSingleCoil(addr uint8, reg uint16, value bool) error {
+	v := uint16(0)
+	if value {
+		v = coilOn
+	}
+	return c.writeSingle(addr, FuncWriteSingleCoil, reg, v)
+}
+
+// Write a holding register on a server, or all servers if addr is
+// AddrBroadcast
+func (c *Client) WriteSingleRegister(addr uint8, reg uint16, value uint16) error {
+	return c.writeSingle(addr, FuncWriteSingleRegister, reg, value)
+}
+
+// Write coils on a server, or all servers if addr is AddrBroadcast, starting
+// at address start
+func (c *Client) WriteMultipleCoils(addr uint8, start uint16, values []bool) error {
+	if err := assert(len(values) > 0 && len(values) <= MaxWriteBits, ErrBadParameter.With("WriteMultipleCoils: count ", len(values))); err != nil {
+		return err
+	}
+	b := packBits(values)
+	req := put16(put16([]byte{byte(FuncWriteMultipleCoils)}, start), uint16(len(values)))
+	req = append(append(req, byte(len(b))), b...)
+	return c.writeMultiple(addr, req)
+}
+
+// Write holding registers on a server, or all servers if addr is
+// AddrBroadcast, starting at address start
+func (c *Client) WriteMultipleRegisters(addr uint8, start uint16, values []uint16) error {
+	if err := assert(len(values) > 0 && len(values) <= MaxWriteRegisters, ErrBadParameter.With("WriteMultipleRegisters: count ", len(values))); err != nil {
+		return err
+	}
+	req := put16(put16([]byte{byte(FuncWriteMultipleRegisters)}, start), uint16(len(values)))
+	req = append(req, byte(len(values)*2))
+	for _, v := range values {
+		req = put16(req, v)
+	}
+	return c.writeMultiple(addr, req)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Send a request and return the response, or nil for a broadcast request.
+// If the server returns an exception, it is returned as the error.
+func (c *Client) request(addr uint8, req []byte) ([]byte, error) {
+	if err := assert(addr <= AddrMax, ErrBadParameter.With("address ", addr)); err != nil {
+		return nil, err
+	}
+	if err := c.write(addr, req); err != nil {
+		return nil, err
+	} else if addr == AddrBroadcast {
+		return nil, nil
+	}
+
+	// Read the response, and discard the rest of a bad frame
+	adu, err := c.read(c.Timeout, responseLength)
+	if errors.Is(err, ErrUnexpectedValue) || errors.Is(err, ErrOverflow) {
+		c.flush()
+		return nil, err
+	} else if err != nil {
+		return nil, err
+	}
+	raddr, resp, err := Decode(adu)
+	if err != nil {
+		c.flush()
+		return nil, err
+	} else if raddr != addr {
+		return nil, ErrUnexpectedValue.With("response from address ", raddr)
+	} else if resp[0] == req[0]|exceptionFlag && len(resp) == 2 {
+		return nil, Exception(resp[1])
+	} else if resp[0] != req[0] {
+		return nil, ErrUnexpectedValue.With("response function ", resp[0])
+	}
+	return resp, nil
+}
+
+func (c *Client) readBits(addr uint8, fn Function, start, count uint16) ([]bool, error) {
+	if err := assert(addr != AddrBroadcast, ErrBadParameter.With("read from broadcast address")); err != nil {
+		return nil, err
+	}
+	if err := assert(count > 0 && count <= MaxReadBits, ErrBadParameter.With("read count ", count)); err != nil {
+		return nil, err
+	}
+	resp, err := c.request(addr, put16(put16([]byte{byte(fn)}, start), count))
+	if err != nil {
+		return nil, err
+	}
+	if n := (int(count) + 7) / 8; int(resp[1]) != n || len(resp) != 2+n {
+		return nil, ErrUnexpectedValue.With("response byte count ", resp[1])
+	}
+	return unpackBits(resp[2:], int(count)), nil
+}
+
+func (c *Client) readRegisters(addr uint8, fn Function, start, count uint16) ([]uint16, error) {
+	if err := assert(addr != AddrBroadcast, ErrBadParameter.With("read from broadcast address")); err != nil {
+		return nil, err
+	}
+	if err := assert(count > 0 && count <= MaxReadRegisters, ErrBadParameter.With("read count ", count)); err != nil {
+		return nil, err
+	}
+	resp, err := c.request(addr, put16(put16([]byte{byte(fn)}, start), count))
+	if err != nil {
+		return nil, err
+	}
+	if n := int(count) * 2; int(resp[1]) != n || len(resp) != 2+n {
+		return nil, ErrUnexpectedValue.With("response byte count ", resp[1])
+	}
+	regs := make([]uint16, count)
+	for i := range regs {
+		regs[i] = get16(resp[2+i*2:])
+	}
+	return regs, nil
+}
+
+// Write a single coil or register, where the response echoes the request
+func (c *Client) writeSingle(addr uint8, fn Function, reg, value uint16) error {
+	req := put16(put16([]byte{byte(fn)}, reg), value)
+	if resp, err := c.request(addr, req); err != nil {
+		return err
+	} else if resp != nil && string(resp) != string(req) {
+		return ErrUnexpectedValue.With("response does not match request")
+	}
+	return nil
+}
+
+// Write multiple coils or registers, where the response echoes the start
+// address and count of the request
+func (c *Client) writeMultiple(addr uint8, req []byte) error {
+	if resp, err := c.request(addr, req); err != nil {
+		return err
+	} else if resp != nil && string(resp) != string(req[:5]) {
+		return ErrUnexpectedValue.With("response does not match request")
+	}
+	return nil
+}
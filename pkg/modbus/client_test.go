@@ -0,0 +1,263 @@
+package modbus_test
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	// Module imports
+	modbus "github.com/djthorpe/go-pico/pkg/modbus"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+func Test_Client_001(t *testing.T) {
+	// Read and write registers and coils over a pipe
+	regs := testRegisters()
+	client, _, _, close := testServer(t, regs)
+	defer close()
+
+	if err := client.WriteMultipleRegisters(testAddr, 1, []uint16{0xBEEF, 0x0102}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.WriteSingleRegister(testAddr, 3, 0x4321); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := client.ReadHoldingRegisters(testAddr, 0, 4); err != nil {
+		t.Fatal(err)
+	} else if len(v) != 4 || v[0] != 0x022B || v[1] != 0xBEEF || v[2] != 0x0102 || v[3] != 0x4321 {
+		t.Errorf("ReadHoldingRegisters = %04X", v)
+	}
+	if v, err := client.ReadInputRegisters(testAddr, 0, 2); err != nil {
+		t.Fatal(err)
+	} else if len(v) != 2 || v[0] != 0x000A || v[1] != 0x1234 {
+		t.Errorf("ReadInputRegisters = %04X", v)
+	}
+
+	coils := []bool{true, false, true, true, false, false, true, true, true}
+	if err := client.WriteMultipleCoils(testAddr, 2, coils); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.WriteSingleCoil(testAddr, 19, true); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := client.ReadCoils(testAddr, 0, 20); err != nil {
+		t.Fatal(err)
+	} else {
+		for i := range v {
+			expected := i == 19 || (i >= 2 && i < 11 && coils[i-2])
+			if v[i] != expected {
+				t.Errorf("ReadCoils[%d] = %v, expected %v", i, v[i], expected)
+			}
+		}
+	}
+	if v, err := client.ReadDiscreteInputs(testAddr, 1, 3); err != nil {
+		t.Fatal(err)
+	} else if len(v) != 3 || v[0] || !v[1] || !v[2] {
+		t.Errorf("ReadDiscreteInputs = %v", v)
+	}
+}
+
+func Test_Client_002(t *testing.T) {
+	// Exceptions are returned as errors
+	client, _, _, close := testServer(t, testRegisters())
+	defer close()
+
+	if _, err := client.ReadHoldingRegisters(testAddr, 2, 3); err != modbus.ExceptionIllegalDataAddress {
+		t.Error("Expected ExceptionIllegalDataAddress, got", err)
+	}
+	if err := client.WriteSingleCoil(testAddr, 100, true); err != modbus.ExceptionIllegalDataAddress {
+		t.Error("Expected ExceptionIllegalDataAddress, got", err)
+	}
+	// The server still responds after an exception
+	if _, err := client.ReadHoldingRegisters(testAddr, 0, 1); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Client_003(t *testing.T) {
+	// Requests to other addresses time out, and broadcast writes are performed
+	// without a response
+	regs := testRegisters()
+	client, _, _, close := testServer(t, regs)
+	defer close()
+
+	if _, err := client.ReadHoldingRegisters(testAddr+1, 0, 1); !errors.Is(err, ErrTimeout) {
+		t.Error("Expected ErrTimeout, got", err)
+	}
+	if _, err := client.ReadHoldingRegisters(modbus.AddrBroadcast, 0, 1); !errors.Is(err, ErrBadParameter) {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if err := client.WriteSingleRegister(modbus.AddrBroadcast, 1, 0x5555); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := client.ReadHoldingRegisters(testAddr, 1, 1); err != nil {
+		t.Fatal(err)
+	} else if v[0] != 0x5555 {
+		t.Errorf("ReadHoldingRegisters = %04X after broadcast", v)
+	}
+}
+
+func Test_Client_004(t *testing.T) {
+	// Frames with a bad CRC, and partial frames, are discarded by the server
+	client, _, conn, close := testServer(t, testRegisters())
+	defer close()
+
+	adu := modbus.Encode(testAddr, []byte{0x06, 0x00, 0x00, 0x12, 0x34})
+	adu[len(adu)-1] ^= 0xFF
+	if _, err := conn.Write(adu); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write(adu[:3]); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * modbus.FrameGap(testBaud))
+	if v, err := client.ReadHoldingRegisters(testAddr, 0, 1); err != nil {
+		t.Fatal(err)
+	} else if v[0] != 0x022B {
+		t.Errorf("ReadHoldingRegisters = %04X, expected 022B", v)
+	}
+}
+
+func Test_Client_005(t *testing.T) {
+	// Responses with a bad CRC return an error
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	client := modbus.NewClient(b, testBaud)
+	go func() {
+		buf := make([]byte, 8)
+		if _, err := a.Read(buf); err != nil {
+			return
+		}
+		adu := modbus.Encode(testAddr, []byte{0x03, 0x02, 0x00, 0x01})
+		adu[4] ^= 0xFF
+		a.Write(adu)
+	}()
+	if _, err := client.ReadHoldingRegisters(testAddr, 0, 1); !errors.Is(err, ErrUnexpectedValue) {
+		t.Error("Expected ErrUnexpectedValue, got", err)
+	}
+}
+
+func Test_Client_006(t *testing.T) {
+	// A response sent one byte at a time is received, although it takes
+	// longer than the frame gap, as the timeout is reset after each byte
+	const baud = 1200
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	client := modbus.NewClient(b, baud)
+	go func() {
+		buf := make([]byte, 8)
+		if _, err := a.Read(buf); err != nil {
+			return
+		}
+		adu := modbus.Encode(testAddr, []byte{0x03, 0x0A, 0x00, 0x00, 0x00, 0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x04})
+		for _, v := range adu {
+			time.Sleep(modbus.FrameGap(baud) / 8)
+			a.Write([]byte{v})
+		}
+	}()
+	if regs, err := client.ReadHoldingRegisters(testAddr, 0, 5); err != nil {
+		t.Error(err)
+	} else if len(regs) != 5 || regs[4] != 4 {
+		t.Error("Unexpected registers", regs)
+	}
+}
+
+func Test_Client_007(t *testing.T) {
+	// A response with a gap of more than the read gap is discarded
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	client := modbus.NewClient(b, testBaud)
+	go func() {
+		buf := make([]byte, 8)
+		if _, err := a.Read(buf); err != nil {
+			return
+		}
+		adu := modbus.Encode(testAddr, []byte{0x03, 0x02, 0x00, 0x01})
+		a.Write(adu[:3])
+		time.Sleep(2 * modbus.ReadGap(testBaud))
+		a.Write(adu[3:])
+	}()
+	if _, err := client.ReadHoldingRegisters(testAddr, 0, 1); !errors.Is(err, ErrUnexpectedValue) {
+		t.Error("Expected ErrUnexpectedValue, got", err)
+	}
+}
+
+func Test_Client_008(t *testing.T) {
+	// A response delivered in bursts of 16 bytes, as a buffered UART moves
+	// bytes out of a half-full FIFO, is received
+	const baud = 2400
+	pdu := []byte{0x03, 0x28}
+	for i := 0; i < 20; i++ {
+		pdu = append(pdu, 0x00, byte(i))
+	}
+	uart := &testBurstUART{
+		resp:     modbus.Encode(testAddr, pdu),
+		burst:    16,
+		interval: time.Duration(uint64((16*11+32)*time.Second) / baud),
+	}
+	client := modbus.NewClient(uart, baud)
+	if regs, err := client.ReadHoldingRegisters(testAddr, 0, 20); err != nil {
+		t.Error(err)
+	} else if len(regs) != 20 || regs[19] != 19 {
+		t.Error("Unexpected registers", regs)
+	}
+}
+
+// testBurstUART responds to each frame written with resp, which is made
+// readable burst bytes at a time every interval, and implements the read
+// timeout of UARTBuffered
+type testBurstUART struct {
+	sync.Mutex
+	resp     []byte
+	burst    int
+	interval time.Duration
+	timeout  time.Duration
+	start    time.Time
+	read     int
+}
+
+func (u *testBurstUART) SetReadTimeout(d time.Duration) {
+	u.Lock()
+	defer u.Unlock()
+	u.timeout = d
+}
+
+func (u *testBurstUART) Write(w []byte) (int, error) {
+	u.Lock()
+	defer u.Unlock()
+	u.start = time.Now()
+	u.read = 0
+	return len(w), nil
+}
+
+func (u *testBurstUART) Read(r []byte) (int, error) {
+	u.Lock()
+	defer u.Unlock()
+	var deadline time.Time
+	if u.timeout > 0 {
+		deadline = time.Now().Add(u.timeout)
+	}
+	for {
+		avail := u.burst * int(time.Since(u.start)/u.interval)
+		if avail > len(u.resp) {
+			avail = len(u.resp)
+		}
+		if avail > u.read {
+			n := copy(r, u.resp[u.read:avail])
+			u.read += n
+			return n, nil
+		} else if !deadline.IsZero() && time.Now().After(deadline) {
+			return 0, ErrTimeout.With("read")
+		}
+		u.Unlock()
+		time.Sleep(time.Millisecond)
+		u.Lock()
+	}
+}
@@ -0,0 +1,109 @@
+package modbus
+
+import (
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return a frame for a server address and protocol data unit (the function
+// code and data), with the CRC appended
+func Encode(addr uint8, pdu []byte) []byte {
+	adu := make([]byte, 0, len(pdu)+3)
+	adu = append(adu, addr)
+	adu = append(adu, pdu...)
+	crc := CRC16(adu)
+	return append(adu, byte(crc), byte(crc>>8))
+}
+
+// Return the server address and protocol data unit of a frame. Returns
+// ErrBadParameter if the frame is too short or too long, and
+// ErrUnexpectedValue if the CRC does not match.
+func Decode(adu []byte) (uint8, []byte, error) {
+	if len(adu) < 4 || len(adu) > MaxADU {
+		return 0, nil, ErrBadParameter.With("Decode: frame length ", len(adu))
+	}
+	n := len(adu) - 2
+	if crc := CRC16(adu[:n]); adu[n] != byte(crc) || adu[n+1] != byte(crc>>8) {
+		return 0, nil, ErrUnexpectedValue.With("Decode: CRC")
+	}
+	return adu[0], adu[1:n], nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Return the length of a request frame from the bytes received so far, or
+// zero if more bytes are needed to determine it. Returns -1 if the function
+// code is not supported, in which case the frame ends with a silent interval.
+func requestLength(adu []byte) int {
+	if len(adu) < 2 {
+		return 0
+	}
+	switch Function(adu[1]) {
+	case FuncReadCoils, FuncReadDiscreteInputs, FuncReadHoldingRegisters, FuncReadInputRegisters, FuncWriteSingleCoil, FuncWriteSingleRegister:
+		return 8
+	case FuncWriteMultipleCoils, FuncWriteMultipleRegisters:
+		if len(adu) < 7 {
+			return 0
+		}
+		return 9 + int(adu[6])
+	default:
+		return -1
+	}
+}
+
+// Return the length of a response frame from the bytes received so far, or
+// zero if more bytes are needed to determine it. Returns -1 if the function
+// code is not supported.
+func responseLength(adu []byte) int {
+	if len(adu) < 2 {
+		return 0
+	}
+	if adu[1]&exceptionFlag != 0 {
+		return 5
+	}
+	switch Function(adu[1]) {
+	case FuncReadCoils, FuncReadDiscreteInputs, FuncReadHoldingRegisters, FuncReadInputRegisters:
+		if len(adu) < 3 {
+			return 0
+		}
+		return 5 + int(adu[2])
+	case FuncWriteSingleCoil, FuncWriteSingleRegister, FuncWriteMultipleCoils, FuncWriteMultipleRegisters:
+		return 8
+	default:
+		return -1
+	}
+}
+
+// Return a big-endian 16-bit value
+func get16(b []byte) uint16 {
+	return uint16(b[0])<<8 | uint16(b[1])
+}
+
+// Append a big-endian 16-bit value
+func put16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+// Pack bits into bytes, least significant bit first
+func packBits(bits []bool) []byte {
+	b := make([]byte, (len(bits)+7)/8)
+	for i, v := range bits {
+		if v {
+			b[i/8] |= 1 << (i % 8)
+		}
+	}
+	return b
+}
+
+// Unpack count bits from bytes, least significant bit first
+func unpackBits(b []byte, count int) []bool {
+	bits := make([]bool, count)
+	for i := range bits {
+		bits[i] = b[i/8]&(1<<(i%8)) != 0
+	}
+	return bits
+}
@@ -0,0 +1,128 @@
+package modbus
+
+import (
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Function is a Modbus function code
+type Function uint8
+
+// Exception is the error code returned by a server when it cannot perform a
+// request
+type Exception uint8
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	FuncReadCoils              Function = 1
+	FuncReadDiscreteInputs     Function = 2
+	FuncReadHoldingRegisters   Function = 3
+	FuncReadInputRegisters     Function = 4
+	FuncWriteSingleCoil        Function = 5
+	FuncWriteSingleRegister    Function = 6
+	FuncWriteMultipleCoils     Function = 15
+	FuncWriteMultipleRegisters Function = 16
+)
+
+const (
+	ExceptionIllegalFunction     Exception = 1
+	ExceptionIllegalDataAddress  Exception = 2
+	ExceptionIllegalDataValue    Exception = 3
+	ExceptionServerDeviceFailure Exception = 4
+)
+
+const (
+	AddrBroadcast = 0   // Address for requests to all servers, which do not respond
+	AddrMin       = 1   // Minimum server address
+	AddrMax       = 247 // Maximum server address
+)
+
+const (
+	MaxADU            = 256  // Maximum frame size, including address and CRC
+	MaxReadBits       = 2000 // Maximum coils or discrete inputs read in a request
+	MaxReadRegisters  = 125  // Maximum registers read in a request
+	MaxWriteBits      = 1968 // Maximum coils written in a request
+	MaxWriteRegisters = 123  // Maximum registers written in a request
+)
+
+const (
+	coilOn         = 0xFF00 // Value to set a single coil
+	exceptionFlag  = 0x80   // Set in the function code of an exception response
+	bitsPerChar    = 11     // Start bit, eight data bits, parity or second stop bit, stop bit
+	fixedBaudLimit = 19200  // Above this baud rate, fixed gaps are used
+	rxFifoChars    = 16     // Characters received before a half-full RX FIFO interrupt
+	rxIdleBits     = 32     // Bit periods of silence before an RX timeout interrupt
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (e Exception) Error() string {
+	switch e {
+	case ExceptionIllegalFunction:
+		return "ExceptionIllegalFunction"
+	case ExceptionIllegalDataAddress:
+		return "ExceptionIllegalDataAddress"
+	case ExceptionIllegalDataValue:
+		return "ExceptionIllegalDataValue"
+	case ExceptionServerDeviceFailure:
+		return "ExceptionServerDeviceFailure"
+	default:
+		return "Undefined exception"
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the Modbus CRC16 of data, which is sent least significant byte
+// first
+func CRC16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Return the silent interval which separates frames, which is 3.5 characters
+// at the baud rate. Above 19200 baud it is fixed at 1.75ms.
+func FrameGap(baud uint32) time.Duration {
+	if baud == 0 || baud > fixedBaudLimit {
+		return 1750 * time.Microsecond
+	}
+	return time.Duration(uint64(bitsPerChar*7*time.Second) / (2 * uint64(baud)))
+}
+
+// Return the longest silent interval between characters of a frame, which is
+// 1.5 characters at the baud rate. Above 19200 baud it is fixed at 750µs.
+func CharGap(baud uint32) time.Duration {
+	if baud == 0 || baud > fixedBaudLimit {
+		return 750 * time.Microsecond
+	}
+	return time.Duration(uint64(bitsPerChar*3*time.Second) / (2 * uint64(baud)))
+}
+
+// Return the longest silent interval between reads of a frame. A buffered
+// UART only moves received bytes out of its FIFO when the FIFO is half full
+// or the line has been idle for 32 bit periods, so bytes arrive in bursts.
+// The interval is the frame gap plus the time to receive a half-full FIFO
+// and the idle period.
+func ReadGap(baud uint32) time.Duration {
+	if baud == 0 {
+		return FrameGap(baud)
+	}
+	latency := time.Duration(uint64((rxFifoChars*bitsPerChar+rxIdleBits)*time.Second) / uint64(baud))
+	return FrameGap(baud) + latency
+}
@@ -0,0 +1,81 @@
+package modbus_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	// Module imports
+	modbus "github.com/djthorpe/go-pico/pkg/modbus"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+func Test_Modbus_001(t *testing.T) {
+	// CRC of a read holding registers request, sent as C5 CD
+	if crc := modbus.CRC16([]byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x0A}); crc != 0xCDC5 {
+		t.Errorf("CRC16 = 0x%04X, expected 0xCDC5", crc)
+	}
+	if crc := modbus.CRC16(nil); crc != 0xFFFF {
+		t.Errorf("CRC16(nil) = 0x%04X, expected 0xFFFF", crc)
+	}
+}
+
+func Test_Modbus_002(t *testing.T) {
+	// Encode appends the CRC least significant byte first
+	adu := modbus.Encode(0x01, []byte{0x03, 0x00, 0x00, 0x00, 0x0A})
+	if !bytes.Equal(adu, []byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x0A, 0xC5, 0xCD}) {
+		t.Errorf("Encode = % X", adu)
+	}
+	addr, pdu, err := modbus.Decode(adu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != 0x01 || !bytes.Equal(pdu, []byte{0x03, 0x00, 0x00, 0x00, 0x0A}) {
+		t.Errorf("Decode = %v, % X", addr, pdu)
+	}
+}
+
+func Test_Modbus_003(t *testing.T) {
+	// Decode rejects short frames and bad CRCs
+	if _, _, err := modbus.Decode([]byte{0x01, 0x03, 0xC5}); !errors.Is(err, ErrBadParameter) {
+		t.Error("Expected ErrBadParameter, got", err)
+	}
+	if _, _, err := modbus.Decode([]byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x0A, 0xC5, 0xCE}); !errors.Is(err, ErrUnexpectedValue) {
+		t.Error("Expected ErrUnexpectedValue, got", err)
+	}
+}
+
+func Test_Modbus_004(t *testing.T) {
+	// Frame gap is 3.5 characters of 11 bits, fixed above 19200 baud. The
+	// read gap adds 16 characters and 32 bits at the baud rate.
+	tests := []struct {
+		baud            uint32
+		frame, ch, read time.Duration
+	}{
+		{9600, 4010416 * time.Nanosecond, 1718750 * time.Nanosecond, 25677082 * time.Nanosecond},
+		{19200, 2005208 * time.Nanosecond, 859375 * time.Nanosecond, 12838541 * time.Nanosecond},
+		{115200, 1750 * time.Microsecond, 750 * time.Microsecond, 3555555 * time.Nanosecond},
+	}
+	for _, test := range tests {
+		if d := modbus.FrameGap(test.baud); d != test.frame {
+			t.Errorf("FrameGap(%v) = %v, expected %v", test.baud, d, test.frame)
+		}
+		if d := modbus.CharGap(test.baud); d != test.ch {
+			t.Errorf("CharGap(%v) = %v, expected %v", test.baud, d, test.ch)
+		}
+		if d := modbus.ReadGap(test.baud); d != test.read {
+			t.Errorf("ReadGap(%v) = %v, expected %v", test.baud, d, test.read)
+		}
+	}
+}
+
+func Test_Modbus_005(t *testing.T) {
+	// Exceptions are errors
+	var err error = modbus.ExceptionIllegalDataAddress
+	if err.Error() != "ExceptionIllegalDataAddress" {
+		t.Error("Unexpected error", err)
+	}
+}
@@ -0,0 +1,144 @@
+package modbus
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// port reads and writes frames, separated by silent intervals
+type port struct {
+	rw   io.ReadWriter
+	baud uint32
+	last time.Time
+	buf  [MaxADU]byte
+}
+
+// deadline is implemented by net.Conn
+type deadline interface {
+	SetReadDeadline(time.Time) error
+}
+
+// timeouter is implemented by UARTBuffered
+type timeouter interface {
+	SetReadTimeout(time.Duration)
+}
+
+// timeout is implemented by errors returned when a deadline passes
+type timeout interface {
+	Timeout() bool
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Set the read timeout, where zero blocks until data is received. Does
+// nothing if the reader does not support timeouts.
+func (p *port) setTimeout(d time.Duration) {
+	switch rw := p.rw.(type) {
+	case deadline:
+		if d == 0 {
+			rw.SetReadDeadline(time.Time{})
+		} else {
+			rw.SetReadDeadline(time.Now().Add(d))
+		}
+	case timeouter:
+		rw.SetReadTimeout(d)
+	}
+}
+
+// Read a frame, waiting up to d for the first byte and then up to the read
+// gap for each following read. The frame ends when length returns
+// the number of bytes received, or for unknown function codes when the line
+// is silent. Returns ErrTimeout if no bytes are received, ErrUnexpectedValue
+// if the line is silent before the frame is complete, and ErrOverflow if the
+// frame is too long.
+func (p *port) read(d time.Duration, length func([]byte) int) ([]byte, error) {
+	n := 0
+	p.setTimeout(d)
+	for {
+		// Read up to the end of the frame, or one byte at a time until the
+		// length of the frame is known
+		want := length(p.buf[:n])
+		if want > MaxADU {
+			return nil, ErrOverflow.With("frame length ", want)
+		} else if want > 0 && n >= want {
+			p.last = time.Now()
+			return p.buf[:want], nil
+		} else if n >= MaxADU {
+			return nil, ErrOverflow.With("frame length ", n)
+		}
+		end := want
+		if end <= 0 {
+			end = n + 1
+		}
+
+		// Read bytes, and set the timeout between reads after each read, so
+		// that the whole frame is not limited to the timeout. The timeout
+		// allows for bytes which are delivered in bursts by a buffered UART.
+		r, err := p.rw.Read(p.buf[n:end])
+		if r > 0 {
+			p.setTimeout(ReadGap(p.baud))
+			n += r
+			p.last = time.Now()
+		}
+		if err == nil {
+			continue
+		} else if !isTimeout(err) {
+			return nil, err
+		} else if n == 0 {
+			return nil, ErrTimeout.With("read")
+		} else if want < 0 {
+			return p.buf[:n], nil
+		} else {
+			return nil, ErrUnexpectedValue.With("partial frame of ", n, " bytes")
+		}
+	}
+}
+
+// Discard bytes until the line is silent, in order to find the start of the
+// next frame. Does nothing if the reader does not support timeouts.
+func (p *port) flush() error {
+	switch p.rw.(type) {
+	case deadline, timeouter:
+		break
+	default:
+		return nil
+	}
+	for {
+		p.setTimeout(FrameGap(p.baud))
+		if _, err := p.rw.Read(p.buf[:]); isTimeout(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		p.last = time.Now()
+	}
+}
+
+// Write a frame, after the line has been silent for the frame gap
+func (p *port) write(addr uint8, pdu []byte) error {
+	if d := time.Until(p.last.Add(FrameGap(p.baud))); d > 0 {
+		time.Sleep(d)
+	}
+	_, err := p.rw.Write(Encode(addr, pdu))
+	p.last = time.Now()
+	return err
+}
+
+// Return true if the error is a read timeout
+func isTimeout(err error) bool {
+	if err == nil {
+		return false
+	} else if errors.Is(err, ErrTimeout) {
+		return true
+	}
+	var t timeout
+	return errors.As(err, &t) && t.Timeout()
+}
@@ -0,0 +1,232 @@
+package modbus
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// RegisterMap holds the data which a server reads and writes. The mutex is
+// held while a request is handled, and should be held when the data is
+// accessed from elsewhere.
+type RegisterMap struct {
+	sync.Mutex
+	Coils            []bool
+	DiscreteInputs   []bool
+	HoldingRegisters []uint16
+	InputRegisters   []uint16
+}
+
+// Server responds to requests for a server address
+type Server struct {
+	port
+	addr     uint8
+	regs     *RegisterMap
+	callback Server_callback_t
+}
+
+// Server_callback_t is called after coils or holding registers are written,
+// with the function code, first address and count
+type Server_callback_t func(fn Function, addr, count uint16)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Create a server for an address between 1 and 247, which reads and writes
+// frames at a baud rate
+func NewServer(rw io.ReadWriter, addr uint8, baud uint32, regs *RegisterMap) (*Server, error) {
+	if err := assert(rw != nil && regs != nil, ErrBadParameter.With("NewServer")); err != nil {
+		return nil, err
+	}
+	if err := assert(addr >= AddrMin && addr <= AddrMax, ErrBadParameter.With("NewServer: address ", addr)); err != nil {
+		return nil, err
+	}
+	return &Server{port: port{rw: rw, baud: baud}, addr: addr, regs: regs}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the server address
+func (s *Server) Addr() uint8 {
+	return s.addr
+}
+
+// Set the callback for writes. If called with nil then the callback is
+// disabled.
+func (s *Server) SetCallback(callback Server_callback_t) {
+	s.callback = callback
+}
+
+// Read requests and write responses until the reader returns an error. Frames
+// with a bad CRC, or which are incomplete, are discarded. Requests to the
+// broadcast address are handled if they are writes, but no response is sent.
+func (s *Server) Serve() error {
+	for {
+		adu, err := s.read(0, requestLength)
+		if errors.Is(err, ErrUnexpectedValue) || errors.Is(err, ErrOverflow) {
+			if err := s.flush(); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		addr, pdu, err := Decode(adu)
+		if err != nil {
+			if err := s.flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		if addr == AddrBroadcast {
+			if isWrite(Function(pdu[0])) {
+				s.Handle(pdu)
+			}
+		} else if addr == s.addr {
+			if err := s.write(s.addr, s.Handle(pdu)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Handle a request, which is the function code and data, and return the
+// response. If the request cannot be performed, an exception response is
+// returned.
+func (s *Server) Handle(pdu []byte) []byte {
+	if len(pdu) == 0 {
+		return exception(0, ExceptionIllegalFunction)
+	}
+	fn := Function(pdu[0])
+	s.regs.Lock()
+	resp, start, count := s.handle(fn, pdu[1:])
+	s.regs.Unlock()
+	if count > 0 && s.callback != nil {
+		s.callback(fn, start, count)
+	}
+	return resp
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Handle a request with the register map locked, and return the response
+// and, for writes, the addresses written
+func (s *Server) handle(fn Function, data []byte) ([]byte, uint16, uint16) {
+	switch fn {
+	case FuncReadCoils, FuncReadDiscreteInputs:
+		bits := s.regs.Coils
+		if fn == FuncReadDiscreteInputs {
+			bits = s.regs.DiscreteInputs
+		}
+		if len(data) != 4 {
+			return exception(fn, ExceptionIllegalDataValue), 0, 0
+		}
+		start, count := get16(data), get16(data[2:])
+		if count == 0 || count > MaxReadBits {
+			return exception(fn, ExceptionIllegalDataValue), 0, 0
+		} else if int(start)+int(count) > len(bits) {
+			return exception(fn, ExceptionIllegalDataAddress), 0, 0
+		}
+		b := packBits(bits[int(start) : int(start)+int(count)])
+		return append([]byte{byte(fn), byte(len(b))}, b...), 0, 0
+	case FuncReadHoldingRegisters, FuncReadInputRegisters:
+		regs := s.regs.HoldingRegisters
+		if fn == FuncReadInputRegisters {
+			regs = s.regs.InputRegisters
+		}
+		if len(data) != 4 {
+			return exception(fn, ExceptionIllegalDataValue), 0, 0
+		}
+		start, count := get16(data), get16(data[2:])
+		if count == 0 || count > MaxReadRegisters {
+			return exception(fn, ExceptionIllegalDataValue), 0, 0
+		} else if int(start)+int(count) > len(regs) {
+			return exception(fn, ExceptionIllegalDataAddress), 0, 0
+		}
+		resp := []byte{byte(fn), byte(count * 2)}
+		for _, v := range regs[int(start) : int(start)+int(count)] {
+			resp = put16(resp, v)
+		}
+		return resp, 0, 0
+	case FuncWriteSingleCoil:
+		if len(data) != 4 {
+			return exception(fn, ExceptionIllegalDataValue), 0, 0
+		}
+		start, value := get16(data), get16(data[2:])
+		if value != 0 && value != coilOn {
+			return exception(fn, ExceptionIllegalDataValue), 0, 0
+		} else if int(start) >= len(s.regs.Coils) {
+			return exception(fn, ExceptionIllegalDataAddress), 0, 0
+		}
+		s.regs.Coils[start] = value == coilOn
+		return append([]byte{byte(fn)}, data...), start, 1
+	case FuncWriteSingleRegister:
+		if len(data) != 4 {
+			return exception(fn, ExceptionIllegalDataValue), 0, 0
+		}
+		start := get16(data)
+		if int(start) >= len(s.regs.HoldingRegisters) {
+			return exception(fn, ExceptionIllegalDataAddress), 0, 0
+		}
+		s.regs.HoldingRegisters[start] = get16(data[2:])
+		return append([]byte{byte(fn)}, data...), start, 1
+	case FuncWriteMultipleCoils:
+		if len(data) < 5 {
+			return exception(fn, ExceptionIllegalDataValue), 0, 0
+		}
+		start, count, n := get16(data), get16(data[2:]), int(data[4])
+		if count == 0 || count > MaxWriteBits || n != (int(count)+7)/8 || len(data) != 5+n {
+			return exception(fn, ExceptionIllegalDataValue), 0, 0
+		} else if int(start)+int(count) > len(s.regs.Coils) {
+			return exception(fn, ExceptionIllegalDataAddress), 0, 0
+		}
+		copy(s.regs.Coils[start:], unpackBits(data[5:], int(count)))
+		return append([]byte{byte(fn)}, data[:4]...), start, count
+	case FuncWriteMultipleRegisters:
+		if len(data) < 5 {
+			return exception(fn, ExceptionIllegalDataValue), 0, 0
+		}
+		start, count, n := get16(data), get16(data[2:]), int(data[4])
+		if count == 0 || count > MaxWriteRegisters || n != int(count)*2 || len(data) != 5+n {
+			return exception(fn, ExceptionIllegalDataValue), 0, 0
+		} else if int(start)+int(count) > len(s.regs.HoldingRegisters) {
+			return exception(fn, ExceptionIllegalDataAddress), 0, 0
+		}
+		for i := 0; i < int(count); i++ {
+			s.regs.HoldingRegisters[int(start)+i] = get16(data[5+i*2:])
+		}
+		return append([]byte{byte(fn)}, data[:4]...), start, count
+	default:
+		return exception(fn, ExceptionIllegalFunction), 0, 0
+	}
+}
+
+// Return an exception response
+func exception(fn Function, e Exception) []byte {
+	return []byte{byte(fn) | exceptionFlag, byte(e)}
+}
+
+// Return true if the function code writes data
+func isWrite(fn Function) bool {
+	switch fn {
+	case FuncWriteSingleCoil, FuncWriteSingleRegister, FuncWriteMultipleCoils, FuncWriteMultipleRegisters:
+		return true
+	default:
+		return false
+	}
+}
+
+func assert(cond bool, err error) error {
+	if !cond {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package modbus_test
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	// Module imports
+	modbus "github.com/djthorpe/go-pico/pkg/modbus"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+const (
+	testAddr = 0x11
+	testBaud = 9600
+)
+
+// Return a client connected to a server over a pipe, and a function to close
+// the pipe and wait for the server to return
+func testServer(t *testing.T, regs *modbus.RegisterMap) (*modbus.Client, *modbus.Server, net.Conn, func()) {
+	a, b := net.Pipe()
+	server, err := modbus.NewServer(a, testAddr, testBaud, regs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		done <- server.Serve()
+	}()
+	client := modbus.NewClient(b, testBaud)
+	client.Timeout = 100 * time.Millisecond
+	return client, server, b, func() {
+		b.Close()
+		<-done
+		a.Close()
+	}
+}
+
+func testRegisters() *modbus.RegisterMap {
+	return &modbus.RegisterMap{
+		Coils:            make([]bool, 20),
+		DiscreteInputs:   []bool{true, false, true, true, false, false, true, true, true, true, false, true, false, true, true, false, true, false, true, false, true, true},
+		HoldingRegisters: []uint16{0x022B, 0x0000, 0x0064, 0x0000},
+		InputRegisters:   []uint16{0x000A, 0x1234},
+	}
+}
+
+func Test_Server_001(t *testing.T) {
+	// Requests and responses from the Modbus specification
+	server, err := modbus.NewServer(&bytes.Buffer{}, testAddr, testBaud, testRegisters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		req, resp []byte
+	}{
+		{[]byte{0x02, 0x00, 0x00, 0x00, 0x16}, []byte{0x02, 0x03, 0xCD, 0x6B, 0x35}},
+		{[]byte{0x03, 0x00, 0x00, 0x00, 0x03}, []byte{0x03, 0x06, 0x02, 0x2B, 0x00, 0x00, 0x00, 0x64}},
+		{[]byte{0x04, 0x00, 0x01, 0x00, 0x01}, []byte{0x04, 0x02, 0x12, 0x34}},
+		{[]byte{0x05, 0x00, 0x0A, 0xFF, 0x00}, []byte{0x05, 0x00, 0x0A, 0xFF, 0x00}},
+		{[]byte{0x01, 0x00, 0x08, 0x00, 0x04}, []byte{0x01, 0x01, 0x04}},
+		{[]byte{0x06, 0x00, 0x01, 0x00, 0x03}, []byte{0x06, 0x00, 0x01, 0x00, 0x03}},
+		{[]byte{0x0F, 0x00, 0x00, 0x00, 0x0A, 0x02, 0xCD, 0x01}, []byte{0x0F, 0x00, 0x00, 0x00, 0x0A}},
+		{[]byte{0x01, 0x00, 0x00, 0x00, 0x0A}, []byte{0x01, 0x02, 0xCD, 0x01}},
+		{[]byte{0x10, 0x00, 0x01, 0x00, 0x02, 0x04, 0x00, 0x0A, 0x01, 0x02}, []byte{0x10, 0x00, 0x01, 0x00, 0x02}},
+		{[]byte{0x03, 0x00, 0x01, 0x00, 0x02}, []byte{0x03, 0x04, 0x00, 0x0A, 0x01, 0x02}},
+	}
+	for _, test := range tests {
+		if resp := server.Handle(test.req); !bytes.Equal(resp, test.resp) {
+			t.Errorf("Handle(% X) = % X, expected % X", test.req, resp, test.resp)
+		}
+	}
+}
+
+func Test_Server_002(t *testing.T) {
+	// Exceptions, where the count is checked before the address
+	server, err := modbus.NewServer(&bytes.Buffer{}, testAddr, testBaud, testRegisters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		req, resp []byte
+	}{
+		{[]byte{0x07}, []byte{0x87, 0x01}},
+		{[]byte{0x03, 0x00, 0x04, 0x00, 0x01}, []byte{0x83, 0x02}},
+		{[]byte{0x03, 0x00, 0x00, 0x00, 0x00}, []byte{0x83, 0x03}},
+		{[]byte{0x03, 0xFF, 0xFF, 0x00, 0x7E}, []byte{0x83, 0x03}},
+		{[]byte{0x03, 0x00, 0x00}, []byte{0x83, 0x03}},
+		{[]byte{0x05, 0x00, 0x00, 0x12, 0x34}, []byte{0x85, 0x03}},
+		{[]byte{0x05, 0x00, 0x14, 0xFF, 0x00}, []byte{0x85, 0x02}},
+		{[]byte{0x06, 0x00, 0x04, 0x00, 0x00}, []byte{0x86, 0x02}},
+		{[]byte{0x0F, 0x00, 0x00, 0x00, 0x0A, 0x01, 0xCD}, []byte{0x8F, 0x03}},
+		{[]byte{0x10, 0x00, 0x03, 0x00, 0x02, 0x04, 0x00, 0x0A, 0x01, 0x02}, []byte{0x90, 0x02}},
+	}
+	for _, test := range tests {
+		if resp := server.Handle(test.req); !bytes.Equal(resp, test.resp) {
+			t.Errorf("Handle(% X) = % X, expected % X", test.req, resp, test.resp)
+		}
+	}
+}
+
+func Test_Server_003(t *testing.T) {
+	// Server address must be between 1 and 247
+	for _, addr := range []uint8{0, 248} {
+		if _, err := modbus.NewServer(&bytes.Buffer{}, addr, testBaud, testRegisters()); !errors.Is(err, ErrBadParameter) {
+			t.Errorf("NewServer(%v): expected ErrBadParameter, got %v", addr, err)
+		}
+	}
+}
+
+func Test_Server_004(t *testing.T) {
+	// Callback is called after writes
+	server, err := modbus.NewServer(&bytes.Buffer{}, testAddr, testBaud, testRegisters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls []uint16
+	server.SetCallback(func(fn modbus.Function, addr, count uint16) {
+		calls = append(calls, uint16(fn), addr, count)
+	})
+	server.Handle([]byte{0x03, 0x00, 0x00, 0x00, 0x01})
+	server.Handle([]byte{0x06, 0x00, 0x02, 0x00, 0x03})
+	server.Handle([]byte{0x06, 0x00, 0x04, 0x00, 0x03})
+	server.Handle([]byte{0x0F, 0x00, 0x02, 0x00, 0x03, 0x01, 0x07})
+	if expected := []uint16{6, 2, 1, 15, 2, 3}; len(calls) != len(expected) {
+		t.Errorf("Callback calls %v, expected %v", calls, expected)
+	} else {
+		for i := range calls {
+			if calls[i] != expected[i] {
+				t.Errorf("Callback calls %v, expected %v", calls, expected)
+				break
+			}
+		}
+	}
+}
+
+func Test_Server_005(t *testing.T) {
+	// A register map with 65536 entries can be read and written at the last
+	// address, without the end of the range wrapping around
+	regs := &modbus.RegisterMap{
+		Coils:            make([]bool, 0x10000),
+		DiscreteInputs:   make([]bool, 0x10000),
+		HoldingRegisters: make([]uint16, 0x10000),
+		InputRegisters:   make([]uint16, 0x10000),
+	}
+	regs.Coils[0xFFFF] = true
+	regs.InputRegisters[0xFFFF] = 0x1234
+	server, err := modbus.NewServer(&bytes.Buffer{}, testAddr, testBaud, regs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		req, resp []byte
+	}{
+		{[]byte{0x01, 0xFF, 0xFF, 0x00, 0x01}, []byte{0x01, 0x01, 0x01}},
+		{[]byte{0x04, 0xFF, 0xFF, 0x00, 0x01}, []byte{0x04, 0x02, 0x12, 0x34}},
+		{[]byte{0x10, 0xFF, 0xFF, 0x00, 0x01, 0x02, 0xAB, 0xCD}, []byte{0x10, 0xFF, 0xFF, 0x00, 0x01}},
+		{[]byte{0x03, 0xFF, 0xFF, 0x00, 0x01}, []byte{0x03, 0x02, 0xAB, 0xCD}},
+		{[]byte{0x03, 0xFF, 0xFF, 0x00, 0x02}, []byte{0x83, 0x02}},
+	}
+	for _, test := range tests {
+		if resp := server.Handle(test.req); !bytes.Equal(resp, test.resp) {
+			t.Errorf("Handle(% X) = % X, expected % X", test.req, resp, test.resp)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package power
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Point on a discharge curve, the percentage of charge remaining at a
+// battery voltage
+type Point struct {
+	Voltage float32
+	Percent float32
+}
+
+// Curve is a discharge curve, with points in descending order of voltage
+type Curve []Point
+
+// Threshold determines when a value falls below Low, and rises again above
+// High. The gap between them is the hysteresis, which stops a noisy value
+// from changing state repeatedly.
+type Threshold struct {
+	Low   float32
+	High  float32
+	below bool
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	// Typical discharge curve for a single LiPo cell at a low discharge rate
+	LiPo = Curve{
+		{4.20, 100}, {4.15, 95}, {4.11, 90}, {4.08, 85}, {4.02, 80},
+		{3.98, 75}, {3.95, 70}, {3.91, 65}, {3.87, 60}, {3.85, 55},
+		{3.84, 50}, {3.82, 45}, {3.80, 40}, {3.79, 35}, {3.77, 30},
+		{3.75, 25}, {3.73, 20}, {3.71, 15}, {3.69, 10}, {3.61, 5},
+		{3.27, 0},
+	}
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the percentage of charge remaining at a voltage, interpolating
+// linearly between points. Voltages outside the curve are clamped to the
+// first and last points.
+func (c Curve) Percent(v float32) float32 {
+	if len(c) == 0 {
+		return 0
+	}
+	if v >= c[0].Voltage {
+		return c[0].Percent
+	}
+	for i := 1; i < len(c); i++ {
+		hi, lo := c[i-1], c[i]
+		if v >= lo.Voltage {
+			return lo.Percent + (v-lo.Voltage)*(hi.Percent-lo.Percent)/(hi.Voltage-lo.Voltage)
+		}
+	}
+	return c[len(c)-1].Percent
+}
+
+// Update the threshold with a value, and return true if the value is below
+// the threshold. The second return value is true when the state changed.
+func (t *Threshold) Update(v float32) (bool, bool) {
+	switch {
+	case !t.below && v < t.Low:
+		t.below = true
+		return true, true
+	case t.below && v > t.High:
+		t.below = false
+		return false, true
+	default:
+		return t.below, false
+	}
+}
+
+// Return true if the last value was below the threshold
+func (t *Threshold) Below() bool {
+	return t.below
+}
@@ -0,0 +1,158 @@
+//go:build pico
+
+package power
+
+import (
+	// Namespace imports
+	. "github.com/djthorpe/go-pico"
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Board describes how the system voltage and USB power are sensed
+type Board struct {
+	VSYS    Pin     // ADC pin connected to VSYS through a divider
+	Divider float32 // Ratio of VSYS to the voltage at the pin
+	VBUS    Pin     // Pin which is high when USB power is present
+	Battery bool    // True if VSYS is supplied by a LiPo cell
+	STAT    Pin     // Pin connected to the open-drain charger status output
+	HasSTAT bool    // True if the charger status output is connected to STAT
+}
+
+// Power reads the system voltage and USB state, and estimates the charge
+// remaining in the battery
+type Power struct {
+	board    Board
+	adc      *ADC
+	curve    Curve
+	low      Threshold
+	callback Power_callback_t
+}
+
+type Power_callback_t func(p *Power, low bool)
+
+//////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	// Raspberry Pi Pico, which reads VSYS/3 on GP29 and detects VBUS on GP24
+	Pico = Board{VSYS: Pin(29), Divider: 3, VBUS: Pin(24)}
+
+	// Pimoroni Pico LiPo, which reads the battery voltage on GP29 and detects
+	// USB power, which charges the battery, on GP24
+	PicoLiPo = Board{VSYS: Pin(29), Divider: 3, VBUS: Pin(24), Battery: true}
+)
+
+const (
+	_POWER_FILTER_SAMPLES = 9 // Number of samples for each reading of VSYS
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Create a power monitor for a board
+func New(board Board) (*Power, error) {
+	p := &Power{board: board, curve: LiPo}
+
+	// Set the ADC pin, and use a median filter to reject noise from the
+	// switching regulator
+	if adc := board.VSYS.ADC(); adc == nil {
+		return nil, ErrBadParameter.With("VSYS:", board.VSYS)
+	} else if err := adc.SetFilter(FilterMedian, _POWER_FILTER_SAMPLES); err != nil {
+		return nil, err
+	} else {
+		p.adc = adc
+	}
+
+	// Set the VBUS detect pin
+	if err := board.VBUS.SetMode(ModeInput); err != nil {
+		return nil, err
+	}
+
+	// Set the charger status pin, which is pulled low by the charger
+	if board.HasSTAT {
+		if err := board.STAT.SetMode(ModeInputPullup); err != nil {
+			return nil, err
+		}
+	}
+
+	// Return success
+	return p, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the system voltage
+func (p *Power) VSYS() float32 {
+	return p.adc.Voltage() * p.board.Divider
+}
+
+// Return true if USB power is present
+func (p *Power) USB() bool {
+	return p.board.VBUS.Get()
+}
+
+// Return true if the battery is being charged. When the board has a charger
+// status pin it is read, otherwise the battery is assumed to be charging
+// whenever USB power is present.
+func (p *Power) Charging() bool {
+	switch {
+	case !p.board.Battery:
+		return false
+	case p.board.HasSTAT:
+		return !p.board.STAT.Get()
+	default:
+		return p.USB()
+	}
+}
+
+// Return the estimated percentage of charge remaining in the battery, or
+// zero if the board has no battery
+func (p *Power) Battery() float32 {
+	if !p.board.Battery {
+		return 0
+	}
+	return p.curve.Percent(p.VSYS())
+}
+
+// Set the discharge curve for the battery, which defaults to LiPo
+func (p *Power) SetCurve(curve Curve) error {
+	if len(curve) == 0 {
+		return ErrBadParameter.With("SetCurve")
+	}
+	p.curve = curve
+	return nil
+}
+
+// Set the low battery callback, which is called from Update when the battery
+// falls below low percent, and again when it rises above high percent. If
+// called with nil then the callback is disabled.
+func (p *Power) SetLowBattery(low, high float32, callback Power_callback_t) error {
+	if callback != nil && low >= high {
+		return ErrBadParameter.With("SetLowBattery:", low, ",", high)
+	}
+	p.low = Threshold{Low: low, High: high}
+	p.callback = callback
+	return nil
+}
+
+// Return true if the battery is below the low battery threshold
+func (p *Power) Low() bool {
+	return p.low.Below()
+}
+
+// Read the battery, calling the low battery callback if the state has
+// changed, and return the estimated percentage of charge remaining. Should be
+// called periodically.
+func (p *Power) Update() float32 {
+	percent := p.Battery()
+	if p.board.Battery && p.callback != nil {
+		if low, changed := p.low.Update(percent); changed {
+			p.callback(p, low)
+		}
+	}
+	return percent
+}
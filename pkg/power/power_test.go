@@ -0,0 +1,64 @@
+package power_test
+
+import (
+	"math"
+	"testing"
+
+	// Module imports
+	power "github.com/djthorpe/go-pico/pkg/power"
+)
+
+func Test_Power_001(t *testing.T) {
+	// Points on the curve, and interpolation between them
+	tests := []struct {
+		v, percent float32
+	}{
+		{4.20, 100}, {3.84, 50}, {3.27, 0},
+		{3.845, 52.5}, {3.44, 2.5},
+	}
+	for _, test := range tests {
+		if p := power.LiPo.Percent(test.v); math.Abs(float64(p-test.percent)) > 0.01 {
+			t.Errorf("Percent(%v) = %v, expected %v", test.v, p, test.percent)
+		}
+	}
+}
+
+func Test_Power_002(t *testing.T) {
+	// Voltages outside the curve are clamped
+	if p := power.LiPo.Percent(5.0); p != 100 {
+		t.Error("Unexpected percent above curve", p)
+	}
+	if p := power.LiPo.Percent(2.0); p != 0 {
+		t.Error("Unexpected percent below curve", p)
+	}
+	if p := power.Curve(nil).Percent(4.0); p != 0 {
+		t.Error("Unexpected percent for empty curve", p)
+	}
+}
+
+func Test_Power_003(t *testing.T) {
+	// Hysteresis between low and high thresholds
+	threshold := power.Threshold{Low: 10, High: 15}
+	tests := []struct {
+		v              float32
+		below, changed bool
+	}{
+		{50, false, false},
+		{12, false, false},
+		{9, true, true},
+		{12, true, false},
+		{9, true, false},
+		{15, true, false},
+		{16, false, true},
+		{11, false, false},
+	}
+	for i, test := range tests {
+		below, changed := threshold.Update(test.v)
+		if below != test.below || changed != test.changed {
+			t.Errorf("%d: Update(%v) = %v,%v expected %v,%v", i, test.v, below, changed, test.below, test.changed)
+		}
+		if threshold.Below() != test.below {
+			t.Errorf("%d: Below() = %v", i, threshold.Below())
+		}
+	}
+}
@@ -1,7 +1,5 @@
 package sdk
 
-import rp "device/rp"
-
 const (
 	NUM_CORES              = 2
 	NUM_DMA_CHANNELS       = 12
@@ -21,17 +19,3 @@ const (
 	PIO_INSTRUCTION_COUNT  = 32
 	XOSC_MHZ               = 12
 )
-
-// Return the core number the call was made from
-//
-//go:inline
-func get_core_num() uint32 {
-	return rp.SIO.CPUID.Get()
-}
-
-// Return the cpu period in nanoseconds
-//
-//go:inline
-func get_cpu_frequency() uint64 {
-	return 125_000_000
-}
@@ -1,11 +1,16 @@
+//go:build rp2040
+
 package sdk
 
 import (
+	"time"
 	"unsafe"
 
 	// Module imports
 	rp "device/rp"
-	volatile "runtime/volatile"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
 )
 
 // SDK documentation
@@ -14,21 +19,6 @@ import (
 //////////////////////////////////////////////////////////////////////////////
 // CONSTANTS
 
-type adc_t struct {
-	cs     volatile.Register32 // 0x0
-	result volatile.Register32 // 0x4
-	fcs    volatile.Register32 // 0x8
-	fifo   volatile.Register32 // 0xC
-	div    volatile.Register32 // 0x10
-	intr   volatile.Register32 // 0x14
-	inte   volatile.Register32 // 0x18
-	intf   volatile.Register32 // 0x1C
-	ints   volatile.Register32 // 0x20
-}
-
-//////////////////////////////////////////////////////////////////////////////
-// CONSTANTS
-
 const (
 	ADC_BANK0_GPIOS_MIN = 26
 	ADC_BANK0_GPIOS_MAX = 29
@@ -42,27 +32,36 @@ var (
 // METHODS
 
 // Initialise the ADC
-func ADC_init() {
+//
+// Returns ErrTimeout if the ADC does not become ready within ADC_TIMEOUT
+func ADC_init() error {
 	// ADC is in an unknown state. We should start by resetting it
 	reset_block(rp.RESETS_RESET_ADC)
 	unreset_block_wait(rp.RESETS_RESET_ADC)
 
-	// Now turn it back on. Staging of clock etc is handled internally
-	adc.cs.SetBits(rp.ADC_CS_EN)
-
+	// Now turn it back on. Staging of clock etc is handled internally.
 	// Internal staging completes in a few cycles, but poll to be sure
-	for {
-		if ADC_is_ready() {
-			break
-		}
-	}
+	return adc_enable(adc, ADC_TIMEOUT)
 }
 
 // Return ready status for ADC
 //
 //go:inline
 func ADC_is_ready() bool {
-	return adc.cs.HasBits(rp.ADC_CS_READY)
+	return adc.cs.HasBits(_ADC_CS_READY)
+}
+
+// Wait for the ADC to be ready
+//
+// Returns ErrTimeout if any conversion in progress does not complete within the
+// timeout
+//
+//go:inline
+func ADC_wait_ready(timeout time.Duration) error {
+	if !adc_wait_ready(adc, timeout) {
+		return ErrTimeout.With("ADC_wait_ready")
+	}
+	return nil
 }
 
 // Initialise the gpio for use as an ADC pin
@@ -140,8 +139,18 @@ func ADC_set_temp_sensor_enabled(enable bool) {
 	}
 }
 
+// Return true if the onboard temperature sensor is enabled
+//
+//go:inline
+func ADC_is_temp_sensor_enabled() bool {
+	return adc.cs.HasBits(rp.ADC_CS_TS_EN)
+}
+
 // Perform a single conversion
 //
+// Blocks until the conversion is complete. Use ADC_read_timeout in order to
+// give up if the ADC is not running.
+//
 //go:inline
 func ADC_read() uint16 {
 	adc.cs.SetBits(rp.ADC_CS_START_ONCE)
@@ -153,6 +162,15 @@ func ADC_read() uint16 {
 	return uint16(adc.result.Get() & rp.ADC_RESULT_RESULT_Msk)
 }
 
+// Perform a single conversion, waiting for the result
+//
+// Returns ErrTimeout if the conversion does not complete within the timeout
+//
+//go:inline
+func ADC_read_timeout(timeout time.Duration) (uint16, error) {
+	return adc_read(adc, timeout)
+}
+
 // Enable or disable free-running sampling mode
 //
 //go:inline
@@ -164,7 +182,6 @@ func ADC_run(run bool) {
 	}
 }
 
-/*
 // Set the ADC clock divisor
 //
 // Period of samples will be (1 + div) cycles on average. Note it takes 96 cycles to
@@ -172,12 +189,31 @@ func ADC_run(run bool) {
 //
 //go:inline
 func ADC_set_clkdiv(clkdiv float32) {
-	// TODO
-    invalid_params_if(ADC, clkdiv >= 1 << (ADC_DIV_INT_MSB - ADC_DIV_INT_LSB + 1));
+	// Integer part of the divisor is 16 bits, fractional part is 8 bits
+	assert(clkdiv >= 0 && clkdiv < 1<<16)
+	adc.div.Set(uint32(clkdiv * float32(1<<rp.ADC_DIV_INT_Pos)))
+}
+
+// Set the ADC clock divisor using a 16:8 fractional value
+//
+// Period of samples will be (1 + integer + frac/256) cycles on average.
+//
+//go:inline
+func ADC_set_clkdiv_int_frac(integer uint16, frac uint8) {
+	v := uint32(integer)<<rp.ADC_DIV_INT_Pos | uint32(frac)<<rp.ADC_DIV_FRAC_Pos
 	adc.div.Set(v)
-    adc_hw->div = (uint32_t)(clkdiv * (float) (1 << ADC_DIV_INT_LSB));
 }
-*/
+
+// Set the ADC sample rate
+//
+// Sets the clock divisor for free-running conversions, in samples per second
+// across all inputs, and returns the achieved rate. A rate of zero converts
+// back-to-back at the maximum rate.
+func ADC_set_sample_rate(rate uint32) uint32 {
+	integer, frac, actual := ADC_clkdiv_for_rate(rate)
+	ADC_set_clkdiv_int_frac(integer, frac)
+	return actual
+}
 
 // Setup the ADC FIFO
 //
@@ -198,7 +234,7 @@ func ADC_fifo_setup(en, dreq_en bool, dreq_thresh uint16, err_in_fifo, byte_shif
 //
 //go:inline
 func ADC_fifo_is_empty() bool {
-	return adc.fcs.HasBits(rp.ADC_FCS_EMPTY)
+	return adc.fcs.HasBits(_ADC_FCS_EMPTY)
 }
 
 // Get number of entries in the ADC FIFO
@@ -217,43 +253,60 @@ func ADC_fifo_get_level() uint8 {
 //
 //go:inline
 func ADC_fifo_get() uint16 {
-	return uint16(adc.fifo.Get() & rp.ADC_FIFO_VAL_Msk)
+	return uint16(adc.fifo.Get() & _ADC_FIFO_VAL_MSK)
+}
+
+// Get ADC result and conversion error flag from FIFO
+//
+// Pops the latest result from the ADC FIFO. The error flag is only valid when the
+// FIFO has been setup with err_in_fifo set.
+//
+//go:inline
+func ADC_fifo_get_with_err() (uint16, bool) {
+	v := adc.fifo.Get()
+	return uint16(v & _ADC_FIFO_VAL_MSK), v&_ADC_FIFO_ERR != 0
+}
+
+// Get and clear the FIFO overflow and underflow flags
+//
+// Overflow is set when a conversion completed while the FIFO was full, and the
+// result was dropped. Underflow is set when the FIFO was read while empty.
+//
+//go:inline
+func ADC_fifo_get_errors() (bool, bool) {
+	return adc_fifo_get_errors(adc)
+}
+
+// Get and clear the FIFO overflow and underflow flags
+//
+// Returns ErrOverflow or ErrUnderflow if either flag was set, or nil otherwise
+//
+//go:inline
+func ADC_fifo_get_error() error {
+	return adc_fifo_get_error(adc)
 }
 
 // Wait for the ADC FIFO to have data.
 //
-// Blocks until data is present in the FIFO
+// Blocks until data is present in the FIFO, and pops it. Returns ErrTimeout if
+// there is no data within the timeout. The value is returned with ErrOverflow
+// if results were dropped because the FIFO was full, or ErrUnderflow if the
+// FIFO had been read while empty.
 //
 //go:inline
-func ADC_fifo_get_blocking() uint16 {
-	for {
-		if ADC_fifo_is_empty() {
-			break
-		}
-	}
-	return ADC_fifo_get()
+func ADC_fifo_get_blocking(timeout time.Duration) (uint16, error) {
+	return adc_fifo_get_blocking(adc, timeout)
 }
 
 // Drain the ADC FIFO
 //
-// Will wait for any conversion to complete then drain the FIFO, discarding any results
+// Will wait for any conversion to complete then drain the FIFO, discarding any
+// results. Returns ErrTimeout if the conversion does not complete within
+// ADC_TIMEOUT.
 //
 //go:inline
-func ADC_fifo_drain() {
-	// Potentially there is still a conversion in progress
-	// wait for this to complete before draining
-	for {
-		if ADC_is_ready() {
-			break
-		}
-	}
-	// Drain FIFO
-	for {
-		if ADC_fifo_is_empty() {
-			break
-		}
-		ADC_fifo_get()
-	}
+func ADC_fifo_drain() error {
+	return adc_fifo_drain(adc, ADC_TIMEOUT)
 }
 
 // Enable/Disable ADC interrupts.
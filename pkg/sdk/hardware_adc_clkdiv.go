@@ -0,0 +1,57 @@
+package sdk
+
+// SDK documentation
+// https://github.com/raspberrypi/pico-sdk/blob/master/src/rp2_common/hardware_adc
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	ADC_CLOCK_HZ        = 48_000_000                    // ADC clock
+	ADC_CONVERSION      = 96                            // Cycles per conversion
+	ADC_MAX_SAMPLE_RATE = ADC_CLOCK_HZ / ADC_CONVERSION // 500 ksps
+	ADC_MIN_SAMPLE_RATE = ADC_CLOCK_HZ/(1<<16) + 1      // Limited by 16-bit clock divider
+)
+
+const (
+	_ADC_DIV_FRAC_BITS = 8
+	_ADC_DIV_MAX       = 1<<(16+_ADC_DIV_FRAC_BITS) - 1 // 16-bit integer, 8-bit fraction
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// METHODS
+
+// Return the ADC clock divisor for a sample rate
+//
+// Returns the integer and 8-bit fractional parts of the divisor, and the
+// achieved rate in samples per second. The period of samples is (1 + div)
+// cycles of the 48MHz ADC clock. A rate of zero, or above ADC_MAX_SAMPLE_RATE,
+// returns a divisor of zero which converts back-to-back at 500 ksps. Rates
+// below ADC_MIN_SAMPLE_RATE are clamped to the largest divisor.
+func ADC_clkdiv_for_rate(rate uint32) (uint16, uint8, uint32) {
+	if rate == 0 || rate >= ADC_MAX_SAMPLE_RATE {
+		return 0, 0, ADC_MAX_SAMPLE_RATE
+	}
+
+	// Period in 1/256ths of a cycle, rounded to nearest
+	period := (uint64(ADC_CLOCK_HZ)<<_ADC_DIV_FRAC_BITS + uint64(rate)>>1) / uint64(rate)
+	div := period - 1<<_ADC_DIV_FRAC_BITS
+	if div > _ADC_DIV_MAX {
+		div = _ADC_DIV_MAX
+	}
+
+	// Return the divisor and the achieved rate
+	return uint16(div >> _ADC_DIV_FRAC_BITS), uint8(div), ADC_clkdiv_to_rate(uint16(div>>_ADC_DIV_FRAC_BITS), uint8(div))
+}
+
+// Return the sample rate for an ADC clock divisor
+//
+// Returns the rate in samples per second, rounded to nearest. Periods shorter
+// than a conversion are clamped to ADC_CONVERSION cycles.
+func ADC_clkdiv_to_rate(integer uint16, frac uint8) uint32 {
+	period := uint64(integer)<<_ADC_DIV_FRAC_BITS + uint64(frac) + 1<<_ADC_DIV_FRAC_BITS
+	if period < ADC_CONVERSION<<_ADC_DIV_FRAC_BITS {
+		period = ADC_CONVERSION << _ADC_DIV_FRAC_BITS
+	}
+	return uint32((uint64(ADC_CLOCK_HZ)<<_ADC_DIV_FRAC_BITS + period>>1) / period)
+}
@@ -0,0 +1,63 @@
+package sdk_test
+
+import (
+	"testing"
+
+	// Namespace import
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+func Test_ADC_001(t *testing.T) {
+	tests := []struct {
+		rate    uint32
+		integer uint16
+		frac    uint8
+		actual  uint32
+	}{
+		{0, 0, 0, ADC_MAX_SAMPLE_RATE},
+		{ADC_MAX_SAMPLE_RATE, 0, 0, ADC_MAX_SAMPLE_RATE},
+		{1_000_000, 0, 0, ADC_MAX_SAMPLE_RATE},
+		{490_000, 96, 246, 489_991},
+		{400_000, 119, 0, 400_000},
+		{48_000, 999, 0, 48_000},
+		{44_100, 1087, 111, 44_100},
+		{1_000, 47999, 0, 1_000},
+		{ADC_MIN_SAMPLE_RATE, 65483, 80, ADC_MIN_SAMPLE_RATE},
+		{100, 65535, 255, 732},
+		{1, 65535, 255, 732},
+	}
+	for _, test := range tests {
+		integer, frac, actual := ADC_clkdiv_for_rate(test.rate)
+		if integer != test.integer || frac != test.frac {
+			t.Errorf("rate %v: expected divisor %v+%v/256, got %v+%v/256", test.rate, test.integer, test.frac, integer, frac)
+		}
+		if actual != test.actual {
+			t.Errorf("rate %v: expected actual rate %v, got %v", test.rate, test.actual, actual)
+		}
+	}
+}
+
+func Test_ADC_002(t *testing.T) {
+	// Periods shorter than a conversion are clamped
+	for integer := uint16(0); integer < ADC_CONVERSION-1; integer++ {
+		if rate := ADC_clkdiv_to_rate(integer, 0); rate != ADC_MAX_SAMPLE_RATE {
+			t.Errorf("divisor %v: expected rate %v, got %v", integer, ADC_MAX_SAMPLE_RATE, rate)
+		}
+	}
+	if rate := ADC_clkdiv_to_rate(ADC_CONVERSION-1, 0); rate != ADC_MAX_SAMPLE_RATE {
+		t.Errorf("expected rate %v, got %v", ADC_MAX_SAMPLE_RATE, rate)
+	}
+	if rate := ADC_clkdiv_to_rate(ADC_CONVERSION, 0); rate >= ADC_MAX_SAMPLE_RATE {
+		t.Errorf("expected rate below %v, got %v", ADC_MAX_SAMPLE_RATE, rate)
+	}
+}
+
+func Test_ADC_003(t *testing.T) {
+	// Achieved rate is always within 0.01% of the requested rate
+	for rate := uint32(ADC_MIN_SAMPLE_RATE); rate < ADC_MAX_SAMPLE_RATE; rate += 997 {
+		_, _, actual := ADC_clkdiv_for_rate(rate)
+		if diff := int64(actual) - int64(rate); diff*10000 > int64(rate) || -diff*10000 > int64(rate) {
+			t.Errorf("rate %v: achieved rate %v", rate, actual)
+		}
+	}
+}
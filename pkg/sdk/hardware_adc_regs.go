@@ -0,0 +1,113 @@
+package sdk
+
+import (
+	"time"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type adc_t struct {
+	cs     register32 // 0x0
+	result register32 // 0x4
+	fcs    register32 // 0x8
+	fifo   register32 // 0xC
+	div    register32 // 0x10
+	intr   register32 // 0x14
+	inte   register32 // 0x18
+	intf   register32 // 0x1C
+	ints   register32 // 0x20
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	ADC_FIFO_DEPTH = 4                // Number of samples in the FIFO
+	ADC_TIMEOUT    = time.Millisecond // Maximum time for the ADC to become ready
+)
+
+// Register bits, which are the same as in device/rp but are repeated here
+// so that they can be tested on the host
+const (
+	_ADC_CS_EN         = 1 << 0
+	_ADC_CS_START_ONCE = 1 << 2
+	_ADC_CS_READY      = 1 << 8
+	_ADC_RESULT_MSK    = 0xFFF
+	_ADC_FCS_EMPTY     = 1 << 8
+	_ADC_FCS_UNDER     = 1 << 10
+	_ADC_FCS_OVER      = 1 << 11
+	_ADC_FIFO_VAL_MSK  = 0xFFF
+	_ADC_FIFO_ERR      = 1 << 15
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Wait for the ADC to be ready, and return false on timeout
+func adc_wait_ready(hw *adc_t, timeout time.Duration) bool {
+	return wait_until(timeout, func() bool { return hw.cs.HasBits(_ADC_CS_READY) })
+}
+
+// Enable the ADC and wait for it to be ready
+func adc_enable(hw *adc_t, timeout time.Duration) error {
+	hw.cs.SetBits(_ADC_CS_EN)
+	if !adc_wait_ready(hw, timeout) {
+		return ErrTimeout.With("ADC_init")
+	}
+	return nil
+}
+
+// Perform a single conversion, and wait for the result
+func adc_read(hw *adc_t, timeout time.Duration) (uint16, error) {
+	hw.cs.SetBits(_ADC_CS_START_ONCE)
+	if !adc_wait_ready(hw, timeout) {
+		return 0, ErrTimeout.With("ADC_read")
+	}
+	return uint16(hw.result.Get() & _ADC_RESULT_MSK), nil
+}
+
+// Get and clear the FIFO overflow and underflow flags
+func adc_fifo_get_errors(hw *adc_t) (bool, bool) {
+	v := hw.fcs.Get()
+	over, under := v&_ADC_FCS_OVER != 0, v&_ADC_FCS_UNDER != 0
+	// Flags are cleared by writing one, other fields are written back unchanged
+	hw.fcs.Set(v)
+	return over, under
+}
+
+// Return ErrOverflow or ErrUnderflow if the FIFO flags are set, and clear them
+func adc_fifo_get_error(hw *adc_t) error {
+	switch over, under := adc_fifo_get_errors(hw); {
+	case over:
+		return ErrOverflow.With("ADC FIFO")
+	case under:
+		return ErrUnderflow.With("ADC FIFO")
+	default:
+		return nil
+	}
+}
+
+// Wait for data in the FIFO and pop it
+func adc_fifo_get_blocking(hw *adc_t, timeout time.Duration) (uint16, error) {
+	if !wait_until(timeout, func() bool { return !hw.fcs.HasBits(_ADC_FCS_EMPTY) }) {
+		return 0, ErrTimeout.With("ADC_fifo_get_blocking")
+	}
+	value := uint16(hw.fifo.Get() & _ADC_FIFO_VAL_MSK)
+	return value, adc_fifo_get_error(hw)
+}
+
+// Wait for any conversion to complete, then drain the FIFO. Conversions
+// should have been stopped, or the FIFO may not remain empty.
+func adc_fifo_drain(hw *adc_t, timeout time.Duration) error {
+	if !adc_wait_ready(hw, timeout) {
+		return ErrTimeout.With("ADC_fifo_drain")
+	}
+	for i := 0; i < ADC_FIFO_DEPTH && !hw.fcs.HasBits(_ADC_FCS_EMPTY); i++ {
+		hw.fifo.Get()
+	}
+	return nil
+}
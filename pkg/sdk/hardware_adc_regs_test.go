@@ -0,0 +1,195 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"unsafe"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+// Return a fake ADC which is ready, and where the FIFO holds values. Reading
+// the FIFO when empty sets the underflow flag, and the flags are cleared by
+// writing one
+func fake_adc(t *testing.T, values ...uint16) *adc_t {
+	hw := new(adc_t)
+	hw.cs.Reg = _ADC_CS_EN | _ADC_CS_READY
+	register_fakes[&hw.fcs] = register_fake{
+		get: func() uint32 {
+			v := hw.fcs.Reg &^ _ADC_FCS_EMPTY
+			if len(values) == 0 {
+				v |= _ADC_FCS_EMPTY
+			}
+			return v
+		},
+		set: func(v uint32) {
+			// Flags are cleared by writing one
+			hw.fcs.Reg &^= v & (_ADC_FCS_OVER | _ADC_FCS_UNDER)
+		},
+	}
+	register_fakes[&hw.fifo] = register_fake{
+		get: func() uint32 {
+			if len(values) == 0 {
+				hw.fcs.Reg |= _ADC_FCS_UNDER
+				return 0
+			}
+			v := values[0]
+			values = values[1:]
+			return uint32(v)
+		},
+	}
+	t.Cleanup(func() {
+		delete(register_fakes, &hw.fcs)
+		delete(register_fakes, &hw.fifo)
+	})
+	return hw
+}
+
+func Test_ADC_Regs_001(t *testing.T) {
+	// Register layout matches the datasheet
+	hw := adc_t{}
+	if size := unsafe.Sizeof(hw); size != 0x24 {
+		t.Errorf("Unexpected size 0x%X", size)
+	}
+	if offset := unsafe.Offsetof(hw.fifo); offset != 0xC {
+		t.Errorf("Unexpected fifo offset 0x%X", offset)
+	}
+	if offset := unsafe.Offsetof(hw.ints); offset != 0x20 {
+		t.Errorf("Unexpected ints offset 0x%X", offset)
+	}
+}
+
+func Test_ADC_Regs_002(t *testing.T) {
+	// Enable times out when the ADC never becomes ready
+	hw := new(adc_t)
+	start := time.Now()
+	if err := adc_enable(hw, 5*time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Error("Expected ErrTimeout, got", err)
+	}
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Error("Returned early after", elapsed)
+	}
+	if !hw.cs.HasBits(_ADC_CS_EN) {
+		t.Error("Expected ADC to be enabled")
+	}
+
+	// Enable succeeds once the ADC is ready
+	hw.cs.Reg |= _ADC_CS_READY
+	if err := adc_enable(hw, 0); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_ADC_Regs_003(t *testing.T) {
+	// Single conversion returns the 12-bit result
+	hw := new(adc_t)
+	hw.result.Reg = 0xF123
+	register_fakes[&hw.cs] = register_fake{
+		set: func(v uint32) {
+			// Conversion completes immediately, and the start bit self-clears
+			hw.cs.Reg = (v &^ _ADC_CS_START_ONCE) | _ADC_CS_READY
+		},
+	}
+	defer delete(register_fakes, &hw.cs)
+	if v, err := adc_read(hw, time.Millisecond); err != nil {
+		t.Error(err)
+	} else if v != 0x123 {
+		t.Errorf("Unexpected value 0x%X", v)
+	}
+
+	// Conversion which never completes times out
+	register_fakes[&hw.cs] = register_fake{
+		get: func() uint32 { return _ADC_CS_EN },
+	}
+	if _, err := adc_read(hw, time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Error("Expected ErrTimeout, got", err)
+	}
+}
+
+func Test_ADC_Regs_004(t *testing.T) {
+	// Blocking read waits for data rather than for the FIFO to empty
+	hw := fake_adc(t, 0x100, 0x200)
+	for _, expected := range []uint16{0x100, 0x200} {
+		if v, err := adc_fifo_get_blocking(hw, time.Millisecond); err != nil {
+			t.Error(err)
+		} else if v != expected {
+			t.Errorf("Expected 0x%X, got 0x%X", expected, v)
+		}
+	}
+
+	// Empty FIFO times out without reading
+	if _, err := adc_fifo_get_blocking(hw, time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Error("Expected ErrTimeout, got", err)
+	}
+	if hw.fcs.Reg&_ADC_FCS_UNDER != 0 {
+		t.Error("Unexpected read of empty FIFO")
+	}
+}
+
+func Test_ADC_Regs_005(t *testing.T) {
+	// Data arrives after a delay
+	hw := new(adc_t)
+	hw.fifo.Reg = 0x321
+	ready := time.Now().Add(2 * time.Millisecond)
+	register_fakes[&hw.fcs] = register_fake{
+		get: func() uint32 {
+			if time.Now().Before(ready) {
+				return _ADC_FCS_EMPTY
+			}
+			return 1 << 16 // Level is one
+		},
+	}
+	defer delete(register_fakes, &hw.fcs)
+	if v, err := adc_fifo_get_blocking(hw, time.Second); err != nil {
+		t.Error(err)
+	} else if v != 0x321 {
+		t.Errorf("Unexpected value 0x%X", v)
+	} else if time.Now().Before(ready) {
+		t.Error("Returned before data arrived")
+	}
+}
+
+func Test_ADC_Regs_006(t *testing.T) {
+	// Overflow and underflow are returned as distinct errors, and cleared
+	hw := fake_adc(t, 0x100, 0x200)
+	hw.fcs.Reg |= _ADC_FCS_OVER
+	if v, err := adc_fifo_get_blocking(hw, 0); !errors.Is(err, ErrOverflow) {
+		t.Error("Expected ErrOverflow, got", err)
+	} else if v != 0x100 {
+		t.Errorf("Unexpected value 0x%X", v)
+	}
+	if _, err := adc_fifo_get_blocking(hw, 0); err != nil {
+		t.Error("Expected flags to be cleared, got", err)
+	}
+
+	// Underflow, from reading the FIFO when empty
+	hw.fifo.Get()
+	if err := adc_fifo_get_error(hw); !errors.Is(err, ErrUnderflow) {
+		t.Error("Expected ErrUnderflow, got", err)
+	}
+	if err := adc_fifo_get_error(hw); err != nil {
+		t.Error("Expected flags to be cleared, got", err)
+	}
+}
+
+func Test_ADC_Regs_007(t *testing.T) {
+	// Drain empties the FIFO once the ADC is ready
+	hw := fake_adc(t, 1, 2, 3, 4)
+	if err := adc_fifo_drain(hw, time.Millisecond); err != nil {
+		t.Error(err)
+	}
+	if !hw.fcs.HasBits(_ADC_FCS_EMPTY) {
+		t.Error("Expected FIFO to be empty")
+	}
+	if over, under := adc_fifo_get_errors(hw); over || under {
+		t.Error("Unexpected FIFO flags", over, under)
+	}
+
+	// Drain times out when a conversion never completes
+	hw.cs.Reg = _ADC_CS_EN
+	if err := adc_fifo_drain(hw, time.Millisecond); !errors.Is(err, ErrTimeout) {
+		t.Error("Expected ErrTimeout, got", err)
+	}
+}
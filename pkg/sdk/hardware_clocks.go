@@ -0,0 +1,102 @@
+//go:build rp2040
+
+package sdk
+
+import (
+	"unsafe"
+
+	// Module imports
+	rp "device/rp"
+)
+
+// SDK documentation
+// https://github.com/raspberrypi/pico-sdk/tree/master/src/rp2_common/hardware_clocks
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type CLOCK_index_t uint32
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	CLOCK_gpout0 CLOCK_index_t = iota // GPIO Muxing 0
+	CLOCK_gpout1                      // GPIO Muxing 1
+	CLOCK_gpout2                      // GPIO Muxing 2
+	CLOCK_gpout3                      // GPIO Muxing 3
+	CLOCK_ref                         // Watchdog and timers reference clock
+	CLOCK_sys                         // Processors, bus fabric, memory, memory mapped registers
+	CLOCK_peri                        // Peripheral clock for UART and SPI
+	CLOCK_usb                         // USB clock
+	CLOCK_adc                         // ADC clock
+	CLOCK_rtc                         // Real time clock
+	CLOCK_COUNT
+)
+
+const (
+	SYS_CLK_KHZ = 125_000 // System clock, as configured by the runtime before main
+	USB_CLK_KHZ = 48_000  // USB clock, as configured by the runtime before main
+	RTC_CLK_HZ  = 46_875  // Real time clock, which is the USB clock divided by 1024
+)
+
+const (
+	// Frequency counter source for CLOCK_ref, the other clocks follow in order
+	_CLOCKS_FC0_SRC_CLK_REF = 0x08
+)
+
+var (
+	fc0             = (*fc_hw_t)(unsafe.Pointer(uintptr(unsafe.Pointer(rp.CLOCKS)) + _CLOCKS_FC0_OFFSET))
+	configured_freq = [CLOCK_COUNT]uint32{
+		CLOCK_ref:  XOSC_MHZ * 1_000_000,
+		CLOCK_sys:  SYS_CLK_KHZ * 1000,
+		CLOCK_peri: SYS_CLK_KHZ * 1000,
+		CLOCK_usb:  USB_CLK_KHZ * 1000,
+		CLOCK_adc:  USB_CLK_KHZ * 1000,
+		CLOCK_rtc:  RTC_CLK_HZ,
+	}
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Measure a clock frequency using the frequency counter
+//
+// Returns the frequency in kHz, or zero if the measurement did not complete.
+// The reference clock is assumed to be driven from the crystal oscillator.
+func CLOCK_frequency_count_khz(src uint32) uint32 {
+	return clock_fc_count_khz(fc0, XOSC_MHZ*1000, src)
+}
+
+// Get the current frequency of the specified clock
+//
+// Returns the configured frequency, which is the frequency set by the runtime
+// before main unless changed with CLOCK_set_reported_hz. The GPIO output
+// clocks are not configured, and return zero.
+//
+//go:inline
+func CLOCK_get_hz(clk CLOCK_index_t) uint32 {
+	assert(clk < CLOCK_COUNT)
+	return configured_freq[clk]
+}
+
+// Measure the current frequency of the specified clock
+//
+// Blocks while the clock is measured with the frequency counter, and returns
+// the frequency in Hz with a resolution of 1kHz. Returns zero for the GPIO
+// output clocks, which cannot be measured, or if the measurement did not
+// complete.
+func CLOCK_count_hz(clk CLOCK_index_t) uint32 {
+	assert(clk < CLOCK_COUNT)
+	if clk < CLOCK_ref {
+		return 0
+	}
+	return CLOCK_frequency_count_khz(_CLOCKS_FC0_SRC_CLK_REF+uint32(clk-CLOCK_ref)) * 1000
+}
+
+// Set the frequency of a clock as reported by CLOCK_get_hz, without changing
+// the clock
+func CLOCK_set_reported_hz(clk CLOCK_index_t, hz uint32) {
+	assert(clk < CLOCK_COUNT)
+	configured_freq[clk] = hz
+}
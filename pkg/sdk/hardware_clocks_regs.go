@@ -0,0 +1,60 @@
+package sdk
+
+import (
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// fc_hw_t is the register block of the frequency counter
+type fc_hw_t struct {
+	ref_khz  register32 // 0x0
+	min_khz  register32 // 0x4
+	max_khz  register32 // 0x8
+	delay    register32 // 0xC
+	interval register32 // 0x10
+	src      register32 // 0x14
+	status   register32 // 0x18
+	result   register32 // 0x1C
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	_CLOCKS_FC0_OFFSET         = 0x80
+	_CLOCKS_FC0_STATUS_DONE    = 1 << 4
+	_CLOCKS_FC0_STATUS_RUNNING = 1 << 8
+	_CLOCKS_FC0_RESULT_KHZ_POS = 5
+	_CLOCKS_FC0_INTERVAL       = 10 // Test interval, which is 2^10 reference cycles
+	_CLOCKS_FC0_TIMEOUT        = 10 * time.Millisecond
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Measure the frequency of a source in kHz, given the reference frequency in
+// kHz. Returns zero if the measurement does not complete.
+func clock_fc_count_khz(fc *fc_hw_t, ref_khz, src uint32) uint32 {
+	// If frequency counter is running need to wait for it. It runs even if
+	// the source is NULL
+	if !wait_until(_CLOCKS_FC0_TIMEOUT, func() bool { return !fc.status.HasBits(_CLOCKS_FC0_STATUS_RUNNING) }) {
+		return 0
+	}
+
+	// Set reference frequency and interval, with no min or max
+	fc.ref_khz.Set(ref_khz)
+	fc.interval.Set(_CLOCKS_FC0_INTERVAL)
+	fc.min_khz.Set(0)
+	fc.max_khz.Set(0xFFFFFFFF)
+
+	// Set source, which starts the measurement
+	fc.src.Set(src)
+	if !wait_until(_CLOCKS_FC0_TIMEOUT, func() bool { return fc.status.HasBits(_CLOCKS_FC0_STATUS_DONE) }) {
+		return 0
+	}
+
+	// Return the result, ignoring the fractional part
+	return fc.result.Get() >> _CLOCKS_FC0_RESULT_KHZ_POS
+}
@@ -0,0 +1,53 @@
+package sdk
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func Test_Clocks_Regs_001(t *testing.T) {
+	// Register layout matches the datasheet
+	fc := fc_hw_t{}
+	if size := unsafe.Sizeof(fc); size != 0x20 {
+		t.Errorf("Unexpected size 0x%X", size)
+	}
+	if offset := unsafe.Offsetof(fc.result); offset != 0x1C {
+		t.Errorf("Unexpected result offset 0x%X", offset)
+	}
+}
+
+func Test_Clocks_Regs_002(t *testing.T) {
+	// Measurement starts when the source is set, and returns the integer
+	// part of the result in kHz
+	fc := new(fc_hw_t)
+	register_fakes[&fc.src] = register_fake{
+		set: func(v uint32) {
+			fc.src.Reg = v
+			if fc.ref_khz.Reg == 12_000 && v == 0x0A {
+				fc.status.Reg = _CLOCKS_FC0_STATUS_DONE
+				fc.result.Reg = 125_000<<_CLOCKS_FC0_RESULT_KHZ_POS | 0x1F
+			}
+		},
+	}
+	defer delete(register_fakes, &fc.src)
+	if khz := clock_fc_count_khz(fc, 12_000, 0x0A); khz != 125_000 {
+		t.Error("Unexpected frequency", khz)
+	}
+	if fc.max_khz.Reg != 0xFFFFFFFF || fc.interval.Reg != _CLOCKS_FC0_INTERVAL {
+		t.Error("Unexpected configuration", fc.max_khz.Reg, fc.interval.Reg)
+	}
+}
+
+func Test_Clocks_Regs_003(t *testing.T) {
+	// Measurement which never completes returns zero
+	fc := new(fc_hw_t)
+	if khz := clock_fc_count_khz(fc, 12_000, 0x0A); khz != 0 {
+		t.Error("Expected zero, got", khz)
+	}
+
+	// Counter which is always running returns zero
+	fc.status.Reg = _CLOCKS_FC0_STATUS_RUNNING | _CLOCKS_FC0_STATUS_DONE
+	if khz := clock_fc_count_khz(fc, 12_000, 0x0A); khz != 0 {
+		t.Error("Expected zero, got", khz)
+	}
+}
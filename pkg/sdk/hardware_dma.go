@@ -0,0 +1,314 @@
+//go:build rp2040
+
+package sdk
+
+import (
+	"time"
+	"unsafe"
+
+	// Module imports
+	rp "device/rp"
+	interrupt "runtime/interrupt"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+// SDK documentation
+// https://github.com/raspberrypi/pico-sdk/tree/master/src/rp2_common/hardware_dma
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// DMA_irq_callback_t is called from an interrupt when a channel completes
+type DMA_irq_callback_t func(channel uint32)
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DMA_NUM_IRQS = 2 // Number of DMA interrupts, DMA_IRQ_0 and DMA_IRQ_1
+)
+
+var (
+	dma_hw             = (*dma_hw_t)(unsafe.Pointer(rp.DMA))
+	dma_claimed        uint32
+	dma_timers_claimed uint32
+	dma_irq_callback   [DMA_NUM_IRQS]DMA_irq_callback_t
+	dma_irq_channel    [DMA_NUM_IRQS][NUM_DMA_CHANNELS]func(channel uint32)
+	dma_irq_interrupt  = [DMA_NUM_IRQS]interrupt.Interrupt{
+		interrupt.New(rp.IRQ_DMA_IRQ_0, dma_irq0_handler),
+		interrupt.New(rp.IRQ_DMA_IRQ_1, dma_irq1_handler),
+	}
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - CLAIM
+
+// Claim a channel, and return false if it has already been claimed
+func DMA_channel_claim(channel uint32) bool {
+	assert(channel < NUM_DMA_CHANNELS)
+	state := Spin_lock_blocking(PICO_SPINLOCK_ID_HARDWARE_CLAIM)
+	defer Spin_unlock(PICO_SPINLOCK_ID_HARDWARE_CLAIM, state)
+	return dma_claim(&dma_claimed, channel)
+}
+
+// Claim a free channel, and return false if all channels have been claimed
+func DMA_claim_unused_channel() (uint32, bool) {
+	state := Spin_lock_blocking(PICO_SPINLOCK_ID_HARDWARE_CLAIM)
+	defer Spin_unlock(PICO_SPINLOCK_ID_HARDWARE_CLAIM, state)
+	return dma_claim_unused(&dma_claimed, NUM_DMA_CHANNELS)
+}
+
+// Release a claimed channel
+func DMA_channel_unclaim(channel uint32) {
+	assert(channel < NUM_DMA_CHANNELS)
+	state := Spin_lock_blocking(PICO_SPINLOCK_ID_HARDWARE_CLAIM)
+	defer Spin_unlock(PICO_SPINLOCK_ID_HARDWARE_CLAIM, state)
+	dma_claimed &^= 1 << channel
+}
+
+// Return true if a channel has been claimed
+func DMA_channel_is_claimed(channel uint32) bool {
+	assert(channel < NUM_DMA_CHANNELS)
+	return dma_claimed&(1<<channel) != 0
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - CHANNELS
+
+// Return the current configuration of a channel
+func DMA_get_channel_config(channel uint32) *DMA_channel_config {
+	assert(channel < NUM_DMA_CHANNELS)
+	return &DMA_channel_config{ctrl: dma_hw.ch[channel].al1_ctrl.Get()}
+}
+
+// Set the configuration of a channel, and start it if trigger is true
+func DMA_channel_set_config(channel uint32, c *DMA_channel_config, trigger bool) {
+	assert(channel < NUM_DMA_CHANNELS)
+	assert(c != nil)
+	dma_channel_set_config(&dma_hw.ch[channel], c, trigger)
+}
+
+// Set the read address of a channel, and start it if trigger is true
+func DMA_channel_set_read_addr(channel uint32, read_addr unsafe.Pointer, trigger bool) {
+	assert(channel < NUM_DMA_CHANNELS)
+	if trigger {
+		dma_hw.ch[channel].al3_read_addr_trig.Set(uint32(uintptr(read_addr)))
+	} else {
+		dma_hw.ch[channel].read_addr.Set(uint32(uintptr(read_addr)))
+	}
+}
+
+// Set the write address of a channel, and start it if trigger is true
+func DMA_channel_set_write_addr(channel uint32, write_addr unsafe.Pointer, trigger bool) {
+	assert(channel < NUM_DMA_CHANNELS)
+	if trigger {
+		dma_hw.ch[channel].al2_write_addr_trig.Set(uint32(uintptr(write_addr)))
+	} else {
+		dma_hw.ch[channel].write_addr.Set(uint32(uintptr(write_addr)))
+	}
+}
+
+// Set the number of transfers for a channel, and start it if trigger is true
+func DMA_channel_set_trans_count(channel, count uint32, trigger bool) {
+	assert(channel < NUM_DMA_CHANNELS)
+	if trigger {
+		dma_hw.ch[channel].al1_transfer_count_trig.Set(count)
+	} else {
+		dma_hw.ch[channel].transfer_count.Set(count)
+	}
+}
+
+// Return the number of transfers remaining for a channel
+func DMA_channel_get_trans_count(channel uint32) uint32 {
+	assert(channel < NUM_DMA_CHANNELS)
+	return dma_hw.ch[channel].transfer_count.Get()
+}
+
+// Configure a channel with a configuration, addresses and the number of
+// transfers, and start it if trigger is true
+func DMA_channel_configure(channel uint32, c *DMA_channel_config, write_addr, read_addr unsafe.Pointer, count uint32, trigger bool) {
+	assert(channel < NUM_DMA_CHANNELS)
+	assert(c != nil)
+	dma_channel_configure(&dma_hw.ch[channel], c, uint32(uintptr(write_addr)), uint32(uintptr(read_addr)), count, trigger)
+}
+
+// Start a channel
+func DMA_channel_start(channel uint32) {
+	assert(channel < NUM_DMA_CHANNELS)
+	dma_hw.multi_channel_trigger.Set(1 << channel)
+}
+
+// Start a mask of channels simultaneously
+func DMA_start_channel_mask(mask uint32) {
+	assert(mask <= _DMA_CHANNEL_MASK)
+	dma_hw.multi_channel_trigger.Set(mask)
+}
+
+// Abort a channel, and wait until any transfers in progress have completed
+func DMA_channel_abort(channel uint32) {
+	assert(channel < NUM_DMA_CHANNELS)
+	dma_channel_abort(dma_hw, channel)
+}
+
+// Return true if a channel is transferring data
+func DMA_channel_is_busy(channel uint32) bool {
+	assert(channel < NUM_DMA_CHANNELS)
+	return dma_channel_is_busy(&dma_hw.ch[channel])
+}
+
+// Wait for a channel to complete
+func DMA_channel_wait_for_finish_blocking(channel uint32) {
+	assert(channel < NUM_DMA_CHANNELS)
+	dma_channel_wait_for_finish(&dma_hw.ch[channel], 0)
+}
+
+// Wait for a channel to complete
+//
+// Returns ErrTimeout if the channel does not complete within the timeout
+func DMA_channel_wait_for_finish(channel uint32, timeout time.Duration) error {
+	assert(channel < NUM_DMA_CHANNELS)
+	if !dma_channel_wait_for_finish(&dma_hw.ch[channel], timeout) {
+		return ErrTimeout.With("DMA channel ", channel)
+	}
+	return nil
+}
+
+// Return true if the channel has a read or write bus error. The error is
+// cleared when the channel is started again.
+func DMA_channel_get_errors(channel uint32) (bool, bool) {
+	assert(channel < NUM_DMA_CHANNELS)
+	ctrl := dma_hw.ch[channel].al1_ctrl.Get()
+	return ctrl&_DMA_CTRL_READ_ERROR != 0, ctrl&_DMA_CTRL_WRITE_ERROR != 0
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - INTERRUPTS
+
+// Enable or disable DMA_IRQ_0 or DMA_IRQ_1 for a channel
+func DMA_irqn_set_channel_enabled(irq_index, channel uint32, enabled bool) {
+	assert(irq_index < DMA_NUM_IRQS)
+	assert(channel < NUM_DMA_CHANNELS)
+	dma_irqn_set_channel_mask_enabled(&dma_hw.irq_ctrl[irq_index], 1<<channel, enabled)
+}
+
+// Enable or disable DMA_IRQ_0 or DMA_IRQ_1 for a mask of channels
+func DMA_irqn_set_channel_mask_enabled(irq_index, mask uint32, enabled bool) {
+	assert(irq_index < DMA_NUM_IRQS)
+	assert(mask <= _DMA_CHANNEL_MASK)
+	dma_irqn_set_channel_mask_enabled(&dma_hw.irq_ctrl[irq_index], mask, enabled)
+}
+
+// Return true if DMA_IRQ_0 or DMA_IRQ_1 is pending for a channel
+func DMA_irqn_get_channel_status(irq_index, channel uint32) bool {
+	assert(irq_index < DMA_NUM_IRQS)
+	assert(channel < NUM_DMA_CHANNELS)
+	return dma_hw.irq_ctrl[irq_index].ints.HasBits(1 << channel)
+}
+
+// Acknowledge DMA_IRQ_0 or DMA_IRQ_1 for a channel
+func DMA_irqn_acknowledge_channel(irq_index, channel uint32) {
+	assert(irq_index < DMA_NUM_IRQS)
+	assert(channel < NUM_DMA_CHANNELS)
+	dma_hw.irq_ctrl[irq_index].ints.Set(1 << channel)
+}
+
+// Set the callback for DMA_IRQ_0 or DMA_IRQ_1, which is called from the
+// interrupt for each channel which has completed, after the interrupt has
+// been acknowledged. Channels are enabled with DMA_irqn_set_channel_enabled.
+// Channels with their own callback are not passed to it. If called with nil
+// then the interrupt is disabled, unless channels have their own callback.
+func DMA_irqn_set_callback(irq_index uint32, callback DMA_irq_callback_t) {
+	assert(irq_index < DMA_NUM_IRQS)
+	dma_irq_callback[irq_index] = callback
+	dma_irqn_update_interrupt(irq_index)
+}
+
+// Set the callback for a channel on DMA_IRQ_0 or DMA_IRQ_1, in place of the
+// callback set with DMA_irqn_set_callback, so that several users can share
+// the interrupt. The interrupt is enabled for the channel, or disabled if
+// called with nil.
+func DMA_irqn_set_channel_callback(irq_index, channel uint32, callback DMA_irq_callback_t) {
+	assert(irq_index < DMA_NUM_IRQS)
+	assert(channel < NUM_DMA_CHANNELS)
+	state := interrupt.Disable()
+	dma_irq_channel[irq_index][channel] = callback
+	interrupt.Restore(state)
+	dma_irqn_set_channel_mask_enabled(&dma_hw.irq_ctrl[irq_index], 1<<channel, callback != nil)
+	dma_irqn_update_interrupt(irq_index)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - TIMERS
+
+// Claim a pacing timer, and return false if it has already been claimed
+func DMA_timer_claim(timer uint32) bool {
+	assert(timer < NUM_DMA_TIMERS)
+	state := Spin_lock_blocking(PICO_SPINLOCK_ID_HARDWARE_CLAIM)
+	defer Spin_unlock(PICO_SPINLOCK_ID_HARDWARE_CLAIM, state)
+	return dma_claim(&dma_timers_claimed, timer)
+}
+
+// Claim a free pacing timer, and return false if all timers have been
+// claimed
+func DMA_claim_unused_timer() (uint32, bool) {
+	state := Spin_lock_blocking(PICO_SPINLOCK_ID_HARDWARE_CLAIM)
+	defer Spin_unlock(PICO_SPINLOCK_ID_HARDWARE_CLAIM, state)
+	return dma_claim_unused(&dma_timers_claimed, NUM_DMA_TIMERS)
+}
+
+// Release a claimed pacing timer
+func DMA_timer_unclaim(timer uint32) {
+	assert(timer < NUM_DMA_TIMERS)
+	state := Spin_lock_blocking(PICO_SPINLOCK_ID_HARDWARE_CLAIM)
+	defer Spin_unlock(PICO_SPINLOCK_ID_HARDWARE_CLAIM, state)
+	dma_timers_claimed &^= 1 << timer
+}
+
+// Return true if a pacing timer has been claimed
+func DMA_timer_is_claimed(timer uint32) bool {
+	assert(timer < NUM_DMA_TIMERS)
+	return dma_timers_claimed&(1<<timer) != 0
+}
+
+// Set a pacing timer to request transfers at a fraction x/y of clk_sys,
+// where x is no more than y
+func DMA_timer_set_fraction(timer uint32, x, y uint16) {
+	assert(timer < NUM_DMA_TIMERS)
+	assert(x <= y)
+	dma_hw.timer[timer].Set(dma_timer_fraction(x, y))
+}
+
+// Set a pacing timer to request transfers at a rate, and return the actual
+// rate. Returns zero if the rate is zero or clk_sys is not running.
+func DMA_timer_set_rate(timer, rate uint32) uint32 {
+	assert(timer < NUM_DMA_TIMERS)
+	x, y, rate := DMA_timer_solve(CLOCK_get_hz(CLOCK_sys), rate)
+	dma_hw.timer[timer].Set(dma_timer_fraction(x, y))
+	return rate
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS - INTERRUPTS
+
+func dma_irq0_handler(interrupt.Interrupt) {
+	dma_irqn_dispatch(&dma_hw.irq_ctrl[0], &dma_irq_channel[0], dma_irq_callback[0])
+}
+
+func dma_irq1_handler(interrupt.Interrupt) {
+	dma_irqn_dispatch(&dma_hw.irq_ctrl[1], &dma_irq_channel[1], dma_irq_callback[1])
+}
+
+// Enable the interrupt while there is a callback for it or for any channel
+func dma_irqn_update_interrupt(irq_index uint32) {
+	enabled := dma_irq_callback[irq_index] != nil
+	for _, callback := range dma_irq_channel[irq_index] {
+		enabled = enabled || callback != nil
+	}
+	if enabled {
+		dma_irq_interrupt[irq_index].Enable()
+	} else {
+		dma_irq_interrupt[irq_index].Disable()
+	}
+}
@@ -0,0 +1,354 @@
+package sdk
+
+import (
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// dma_channel_hw_t is the register block of a DMA channel. Each register
+// appears in four aliases, where writing to the last register of an alias
+// triggers the channel.
+type dma_channel_hw_t struct {
+	read_addr               register32 // 0x0
+	write_addr              register32 // 0x4
+	transfer_count          register32 // 0x8
+	ctrl_trig               register32 // 0xC
+	al1_ctrl                register32 // 0x10
+	al1_read_addr           register32 // 0x14
+	al1_write_addr          register32 // 0x18
+	al1_transfer_count_trig register32 // 0x1C
+	al2_ctrl                register32 // 0x20
+	al2_transfer_count      register32 // 0x24
+	al2_read_addr           register32 // 0x28
+	al2_write_addr_trig     register32 // 0x2C
+	al3_ctrl                register32 // 0x30
+	al3_write_addr          register32 // 0x34
+	al3_transfer_count      register32 // 0x38
+	al3_read_addr_trig      register32 // 0x3C
+}
+
+// dma_irq_ctrl_hw_t is the register block for one of the two DMA interrupts.
+// The intr register is only present for the first interrupt.
+type dma_irq_ctrl_hw_t struct {
+	intr register32 // 0x0
+	inte register32 // 0x4
+	intf register32 // 0x8
+	ints register32 // 0xC
+}
+
+// dma_hw_t is the register block of the DMA controller
+type dma_hw_t struct {
+	ch                    [NUM_DMA_CHANNELS]dma_channel_hw_t // 0x0
+	_                     [0x100]byte
+	irq_ctrl              [2]dma_irq_ctrl_hw_t       // 0x400
+	timer                 [NUM_DMA_TIMERS]register32 // 0x420
+	multi_channel_trigger register32                 // 0x430
+	sniff_ctrl            register32                 // 0x434
+	sniff_data            register32                 // 0x438
+	_                     [4]byte
+	fifo_levels           register32 // 0x440
+	abort                 register32 // 0x444
+	n_channels            register32 // 0x448
+}
+
+// DMA_channel_config is the control register value for a channel
+type DMA_channel_config struct {
+	ctrl uint32
+}
+
+type DMA_channel_transfer_size_t uint32
+
+type DMA_dreq_t uint32
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DMA_SIZE_8  DMA_channel_transfer_size_t = 0 // Byte transfers
+	DMA_SIZE_16 DMA_channel_transfer_size_t = 1 // Half-word transfers
+	DMA_SIZE_32 DMA_channel_transfer_size_t = 2 // Word transfers
+)
+
+// Data request signals, which pace transfers to or from a peripheral
+const (
+	DREQ_PIO0_TX0   DMA_dreq_t = 0
+	DREQ_PIO0_RX0   DMA_dreq_t = 4
+	DREQ_PIO1_TX0   DMA_dreq_t = 8
+	DREQ_PIO1_RX0   DMA_dreq_t = 12
+	DREQ_SPI0_TX    DMA_dreq_t = 16
+	DREQ_SPI0_RX    DMA_dreq_t = 17
+	DREQ_SPI1_TX    DMA_dreq_t = 18
+	DREQ_SPI1_RX    DMA_dreq_t = 19
+	DREQ_UART0_TX   DMA_dreq_t = 20
+	DREQ_UART0_RX   DMA_dreq_t = 21
+	DREQ_UART1_TX   DMA_dreq_t = 22
+	DREQ_UART1_RX   DMA_dreq_t = 23
+	DREQ_PWM_WRAP0  DMA_dreq_t = 24
+	DREQ_I2C0_TX    DMA_dreq_t = 32
+	DREQ_I2C0_RX    DMA_dreq_t = 33
+	DREQ_I2C1_TX    DMA_dreq_t = 34
+	DREQ_I2C1_RX    DMA_dreq_t = 35
+	DREQ_ADC        DMA_dreq_t = 36
+	DREQ_XIP_STREAM DMA_dreq_t = 37
+	DREQ_XIP_SSITX  DMA_dreq_t = 38
+	DREQ_XIP_SSIRX  DMA_dreq_t = 39
+	DREQ_DMA_TIMER0 DMA_dreq_t = 59 // Paced by DMA timer 0, and timers 1 to 3 follow
+	DREQ_FORCE      DMA_dreq_t = 63 // Unpaced, transfers run as fast as possible
+)
+
+const (
+	DMA_RING_MAX_SIZE_BITS = 15 // Maximum ring size is 1<<15 bytes
+)
+
+// Register bits, which are the same as in device/rp but are repeated here
+// so that they can be tested on the host
+const (
+	_DMA_CTRL_EN            = 1 << 0
+	_DMA_CTRL_HIGH_PRIORITY = 1 << 1
+	_DMA_CTRL_DATA_SIZE_POS = 2
+	_DMA_CTRL_DATA_SIZE_MSK = 3 << 2
+	_DMA_CTRL_INCR_READ     = 1 << 4
+	_DMA_CTRL_INCR_WRITE    = 1 << 5
+	_DMA_CTRL_RING_SIZE_POS = 6
+	_DMA_CTRL_RING_SIZE_MSK = 0xF << 6
+	_DMA_CTRL_RING_SEL      = 1 << 10
+	_DMA_CTRL_CHAIN_TO_POS  = 11
+	_DMA_CTRL_CHAIN_TO_MSK  = 0xF << 11
+	_DMA_CTRL_TREQ_SEL_POS  = 15
+	_DMA_CTRL_TREQ_SEL_MSK  = 0x3F << 15
+	_DMA_CTRL_IRQ_QUIET     = 1 << 21
+	_DMA_CTRL_BSWAP         = 1 << 22
+	_DMA_CTRL_SNIFF_EN      = 1 << 23
+	_DMA_CTRL_BUSY          = 1 << 24
+	_DMA_CTRL_WRITE_ERROR   = 1 << 29
+	_DMA_CTRL_READ_ERROR    = 1 << 30
+	_DMA_CTRL_AHB_ERROR     = 1 << 31
+	_DMA_TIMER_X_POS        = 16
+	_DMA_CHANNEL_MASK       = 1<<NUM_DMA_CHANNELS - 1
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set whether the read address increments after each transfer. If false,
+// each read is from the same address, which is usual when reading from a
+// peripheral FIFO.
+func DMA_channel_config_set_read_increment(c *DMA_channel_config, incr bool) {
+	assert(c != nil)
+	c.ctrl = c.ctrl&^_DMA_CTRL_INCR_READ | bool_to_bit(incr)*_DMA_CTRL_INCR_READ
+}
+
+// Set whether the write address increments after each transfer. If false,
+// each write is to the same address, which is usual when writing to a
+// peripheral FIFO.
+func DMA_channel_config_set_write_increment(c *DMA_channel_config, incr bool) {
+	assert(c != nil)
+	c.ctrl = c.ctrl&^_DMA_CTRL_INCR_WRITE | bool_to_bit(incr)*_DMA_CTRL_INCR_WRITE
+}
+
+// Set the data request signal which paces transfers. DREQ_FORCE transfers
+// as fast as possible, and DREQ_DMA_TIMER0 to DREQ_DMA_TIMER0+3 are paced by
+// a DMA timer.
+func DMA_channel_config_set_dreq(c *DMA_channel_config, dreq DMA_dreq_t) {
+	assert(c != nil)
+	assert(dreq <= DREQ_FORCE)
+	c.ctrl = c.ctrl&^_DMA_CTRL_TREQ_SEL_MSK | uint32(dreq)<<_DMA_CTRL_TREQ_SEL_POS
+}
+
+// Set the channel which is triggered when this channel completes. Chaining
+// to the same channel disables chaining.
+func DMA_channel_config_set_chain_to(c *DMA_channel_config, chain_to uint32) {
+	assert(c != nil)
+	assert(chain_to < NUM_DMA_CHANNELS)
+	c.ctrl = c.ctrl&^_DMA_CTRL_CHAIN_TO_MSK | chain_to<<_DMA_CTRL_CHAIN_TO_POS
+}
+
+// Set the size of each transfer, which is 8, 16 or 32 bits. The read and
+// write addresses increment by this size.
+func DMA_channel_config_set_transfer_data_size(c *DMA_channel_config, size DMA_channel_transfer_size_t) {
+	assert(c != nil)
+	assert(size <= DMA_SIZE_32)
+	c.ctrl = c.ctrl&^_DMA_CTRL_DATA_SIZE_MSK | uint32(size)<<_DMA_CTRL_DATA_SIZE_POS
+}
+
+// Set address wrapping, so that the write address (if write is true) or read
+// address wraps on a boundary of 1<<size_bits bytes, where the buffer must be
+// aligned to this boundary. A size_bits of zero disables wrapping.
+func DMA_channel_config_set_ring(c *DMA_channel_config, write bool, size_bits uint32) {
+	assert(c != nil)
+	assert(size_bits <= DMA_RING_MAX_SIZE_BITS)
+	c.ctrl = c.ctrl&^(_DMA_CTRL_RING_SIZE_MSK|_DMA_CTRL_RING_SEL) | size_bits<<_DMA_CTRL_RING_SIZE_POS | bool_to_bit(write)*_DMA_CTRL_RING_SEL
+}
+
+// Set whether the bytes of each transfer are reversed
+func DMA_channel_config_set_bswap(c *DMA_channel_config, bswap bool) {
+	assert(c != nil)
+	c.ctrl = c.ctrl&^_DMA_CTRL_BSWAP | bool_to_bit(bswap)*_DMA_CTRL_BSWAP
+}
+
+// Set quiet mode, where an interrupt is only raised when a null trigger is
+// written rather than at the end of every transfer
+func DMA_channel_config_set_irq_quiet(c *DMA_channel_config, quiet bool) {
+	assert(c != nil)
+	c.ctrl = c.ctrl&^_DMA_CTRL_IRQ_QUIET | bool_to_bit(quiet)*_DMA_CTRL_IRQ_QUIET
+}
+
+// Set whether the channel is scheduled before channels without high priority
+func DMA_channel_config_set_high_priority(c *DMA_channel_config, high_priority bool) {
+	assert(c != nil)
+	c.ctrl = c.ctrl&^_DMA_CTRL_HIGH_PRIORITY | bool_to_bit(high_priority)*_DMA_CTRL_HIGH_PRIORITY
+}
+
+// Set whether the channel is enabled. A disabled channel pauses when
+// triggered, and continues when enabled.
+func DMA_channel_config_set_enable(c *DMA_channel_config, enable bool) {
+	assert(c != nil)
+	c.ctrl = c.ctrl&^_DMA_CTRL_EN | bool_to_bit(enable)*_DMA_CTRL_EN
+}
+
+// Set whether data transferred by the channel is passed to the sniffer
+func DMA_channel_config_set_sniff_enable(c *DMA_channel_config, sniff bool) {
+	assert(c != nil)
+	c.ctrl = c.ctrl&^_DMA_CTRL_SNIFF_EN | bool_to_bit(sniff)*_DMA_CTRL_SNIFF_EN
+}
+
+// Return the control register value of a configuration
+func DMA_channel_config_get_ctrl_value(c *DMA_channel_config) uint32 {
+	assert(c != nil)
+	return c.ctrl
+}
+
+// Return the default configuration for a channel, which is enabled, with
+// 32-bit unpaced transfers, an incrementing read address, a fixed write
+// address, and no chaining, wrapping, byte swapping or sniffing
+func DMA_channel_get_default_config(channel uint32) *DMA_channel_config {
+	assert(channel < NUM_DMA_CHANNELS)
+	c := new(DMA_channel_config)
+	DMA_channel_config_set_read_increment(c, true)
+	DMA_channel_config_set_write_increment(c, false)
+	DMA_channel_config_set_dreq(c, DREQ_FORCE)
+	DMA_channel_config_set_chain_to(c, channel)
+	DMA_channel_config_set_transfer_data_size(c, DMA_SIZE_32)
+	DMA_channel_config_set_ring(c, false, 0)
+	DMA_channel_config_set_bswap(c, false)
+	DMA_channel_config_set_irq_quiet(c, false)
+	DMA_channel_config_set_enable(c, true)
+	DMA_channel_config_set_sniff_enable(c, false)
+	DMA_channel_config_set_high_priority(c, false)
+	return c
+}
+
+// Return the data request signal for a DMA timer
+func DMA_get_timer_dreq(timer uint32) DMA_dreq_t {
+	assert(timer < NUM_DMA_TIMERS)
+	return DREQ_DMA_TIMER0 + DMA_dreq_t(timer)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Claim a channel or timer in a bitmask, and return false if it is already
+// claimed
+func dma_claim(claimed *uint32, num uint32) bool {
+	if *claimed&(1<<num) != 0 {
+		return false
+	}
+	*claimed |= 1 << num
+	return true
+}
+
+// Claim the first unclaimed channel or timer in a bitmask of count bits
+func dma_claim_unused(claimed *uint32, count uint32) (uint32, bool) {
+	for num := uint32(0); num < count; num++ {
+		if dma_claim(claimed, num) {
+			return num, true
+		}
+	}
+	return 0, false
+}
+
+// Set the configuration of a channel, and start it if trigger is true
+func dma_channel_set_config(hw *dma_channel_hw_t, c *DMA_channel_config, trigger bool) {
+	if trigger {
+		hw.ctrl_trig.Set(c.ctrl)
+	} else {
+		hw.al1_ctrl.Set(c.ctrl)
+	}
+}
+
+// Configure a channel with addresses and a transfer count, and start it if
+// trigger is true. The control register is written last, so that it
+// triggers the transfer.
+func dma_channel_configure(hw *dma_channel_hw_t, c *DMA_channel_config, write_addr, read_addr, count uint32, trigger bool) {
+	hw.read_addr.Set(read_addr)
+	hw.write_addr.Set(write_addr)
+	hw.transfer_count.Set(count)
+	dma_channel_set_config(hw, c, trigger)
+}
+
+// Return true if a channel is transferring data
+func dma_channel_is_busy(hw *dma_channel_hw_t) bool {
+	return hw.ctrl_trig.HasBits(_DMA_CTRL_BUSY)
+}
+
+// Wait for a channel to complete, and return false if it does not complete
+// within the timeout. A timeout of zero waits forever.
+func dma_channel_wait_for_finish(hw *dma_channel_hw_t, timeout time.Duration) bool {
+	if timeout == 0 {
+		for dma_channel_is_busy(hw) {
+		}
+		return true
+	}
+	return wait_until(timeout, func() bool { return !dma_channel_is_busy(hw) })
+}
+
+// Abort a channel and wait for the abort to complete. The channel may raise
+// an interrupt when aborted (RP2040-E13), so the interrupt is disabled
+// during the abort and any pending interrupt is cleared.
+func dma_channel_abort(hw *dma_hw_t, channel uint32) {
+	mask := uint32(1) << channel
+	inte0, inte1 := hw.irq_ctrl[0].inte.Get(), hw.irq_ctrl[1].inte.Get()
+	hw.irq_ctrl[0].inte.Set(inte0 &^ mask)
+	hw.irq_ctrl[1].inte.Set(inte1 &^ mask)
+	hw.abort.Set(mask)
+	for hw.abort.HasBits(mask) {
+	}
+	hw.irq_ctrl[0].ints.Set(mask)
+	hw.irq_ctrl[1].ints.Set(mask)
+	hw.irq_ctrl[0].inte.Set(inte0)
+	hw.irq_ctrl[1].inte.Set(inte1)
+}
+
+// Enable or disable interrupts for a mask of channels
+func dma_irqn_set_channel_mask_enabled(hw *dma_irq_ctrl_hw_t, mask uint32, enabled bool) {
+	if enabled {
+		hw.inte.SetBits(mask)
+	} else {
+		hw.inte.ClearBits(mask)
+	}
+}
+
+// Acknowledge each channel with a pending interrupt, and then call the
+// callback for the channel, so that the callback can restart it. A callback
+// set for the channel is called in place of the shared callback.
+func dma_irqn_dispatch(hw *dma_irq_ctrl_hw_t, channels *[NUM_DMA_CHANNELS]func(channel uint32), callback func(channel uint32)) {
+	ints := hw.ints.Get() & _DMA_CHANNEL_MASK
+	for channel := uint32(0); ints != 0; channel++ {
+		if mask := uint32(1) << channel; ints&mask != 0 {
+			hw.ints.Set(mask)
+			ints &^= mask
+			if channels != nil && channels[channel] != nil {
+				channels[channel](channel)
+			} else if callback != nil {
+				callback(channel)
+			}
+		}
+	}
+}
+
+// Return the timer register value for a fraction x/y of clk_sys
+func dma_timer_fraction(x, y uint16) uint32 {
+	return uint32(x)<<_DMA_TIMER_X_POS | uint32(y)
+}
@@ -0,0 +1,296 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func Test_DMA_Regs_001(t *testing.T) {
+	// Check the register layout matches the datasheet
+	var hw dma_hw_t
+	if size := unsafe.Sizeof(hw.ch[0]); size != 0x40 {
+		t.Errorf("Unexpected channel size 0x%X", size)
+	}
+	if size := unsafe.Sizeof(hw); size != 0x44C {
+		t.Errorf("Unexpected size 0x%X", size)
+	}
+	base := uintptr(unsafe.Pointer(&hw))
+	offsets := []struct {
+		name   string
+		offset uintptr
+		want   uintptr
+	}{
+		{"CH0_CTRL_TRIG", uintptr(unsafe.Pointer(&hw.ch[0].ctrl_trig)) - base, 0xC},
+		{"CH0_AL1_TRANS_COUNT_TRIG", uintptr(unsafe.Pointer(&hw.ch[0].al1_transfer_count_trig)) - base, 0x1C},
+		{"CH0_AL2_WRITE_ADDR_TRIG", uintptr(unsafe.Pointer(&hw.ch[0].al2_write_addr_trig)) - base, 0x2C},
+		{"CH0_AL3_READ_ADDR_TRIG", uintptr(unsafe.Pointer(&hw.ch[0].al3_read_addr_trig)) - base, 0x3C},
+		{"CH11_READ_ADDR", uintptr(unsafe.Pointer(&hw.ch[11].read_addr)) - base, 0x2C0},
+		{"INTR", uintptr(unsafe.Pointer(&hw.irq_ctrl[0].intr)) - base, 0x400},
+		{"INTE0", uintptr(unsafe.Pointer(&hw.irq_ctrl[0].inte)) - base, 0x404},
+		{"INTS0", uintptr(unsafe.Pointer(&hw.irq_ctrl[0].ints)) - base, 0x40C},
+		{"INTE1", uintptr(unsafe.Pointer(&hw.irq_ctrl[1].inte)) - base, 0x414},
+		{"INTS1", uintptr(unsafe.Pointer(&hw.irq_ctrl[1].ints)) - base, 0x41C},
+		{"TIMER0", unsafe.Offsetof(hw.timer), 0x420},
+		{"MULTI_CHAN_TRIGGER", unsafe.Offsetof(hw.multi_channel_trigger), 0x430},
+		{"SNIFF_CTRL", unsafe.Offsetof(hw.sniff_ctrl), 0x434},
+		{"FIFO_LEVELS", unsafe.Offsetof(hw.fifo_levels), 0x440},
+		{"CHAN_ABORT", unsafe.Offsetof(hw.abort), 0x444},
+		{"N_CHANNELS", unsafe.Offsetof(hw.n_channels), 0x448},
+	}
+	for _, o := range offsets {
+		if o.offset != o.want {
+			t.Errorf("%s: offset 0x%X, expected 0x%X", o.name, o.offset, o.want)
+		}
+	}
+}
+
+func Test_DMA_Regs_002(t *testing.T) {
+	// Default configuration, which chains to itself
+	tests := []struct {
+		channel uint32
+		want    uint32
+	}{
+		{0, 0x001F8019},
+		{5, 0x001FA819},
+		{11, 0x001FD819},
+	}
+	for _, test := range tests {
+		if v := DMA_channel_config_get_ctrl_value(DMA_channel_get_default_config(test.channel)); v != test.want {
+			t.Errorf("DMA_channel_get_default_config(%d) = 0x%08X, expected 0x%08X", test.channel, v, test.want)
+		}
+	}
+}
+
+func Test_DMA_Regs_003(t *testing.T) {
+	// Each setter only changes its own field
+	tests := []struct {
+		name string
+		set  func(c *DMA_channel_config)
+		want uint32
+	}{
+		{"read_increment", func(c *DMA_channel_config) { DMA_channel_config_set_read_increment(c, false) }, 0x001F8009},
+		{"write_increment", func(c *DMA_channel_config) { DMA_channel_config_set_write_increment(c, true) }, 0x001F8039},
+		{"dreq", func(c *DMA_channel_config) { DMA_channel_config_set_dreq(c, DREQ_SPI1_TX) }, 0x00090019},
+		{"timer_dreq", func(c *DMA_channel_config) { DMA_channel_config_set_dreq(c, DMA_get_timer_dreq(2)) }, 0x001E8019},
+		{"chain_to", func(c *DMA_channel_config) { DMA_channel_config_set_chain_to(c, 11) }, 0x001FD819},
+		{"data_size", func(c *DMA_channel_config) { DMA_channel_config_set_transfer_data_size(c, DMA_SIZE_8) }, 0x001F8011},
+		{"ring_read", func(c *DMA_channel_config) { DMA_channel_config_set_ring(c, false, 4) }, 0x001F8119},
+		{"ring_write", func(c *DMA_channel_config) { DMA_channel_config_set_ring(c, true, 15) }, 0x001F87D9},
+		{"bswap", func(c *DMA_channel_config) { DMA_channel_config_set_bswap(c, true) }, 0x005F8019},
+		{"irq_quiet", func(c *DMA_channel_config) { DMA_channel_config_set_irq_quiet(c, true) }, 0x003F8019},
+		{"high_priority", func(c *DMA_channel_config) { DMA_channel_config_set_high_priority(c, true) }, 0x001F801B},
+		{"enable", func(c *DMA_channel_config) { DMA_channel_config_set_enable(c, false) }, 0x001F8018},
+		{"sniff", func(c *DMA_channel_config) { DMA_channel_config_set_sniff_enable(c, true) }, 0x009F8019},
+	}
+	for _, test := range tests {
+		c := DMA_channel_get_default_config(0)
+		test.set(c)
+		if v := DMA_channel_config_get_ctrl_value(c); v != test.want {
+			t.Errorf("%s: 0x%08X, expected 0x%08X", test.name, v, test.want)
+		}
+	}
+
+	// Setting a ring and then clearing it restores the default
+	c := DMA_channel_get_default_config(0)
+	DMA_channel_config_set_ring(c, true, 8)
+	DMA_channel_config_set_ring(c, false, 0)
+	if v := DMA_channel_config_get_ctrl_value(c); v != 0x001F8019 {
+		t.Errorf("ring cleared: 0x%08X", v)
+	}
+}
+
+func Test_DMA_Regs_004(t *testing.T) {
+	// Configure writes addresses and count before the control register, and
+	// only triggers through CTRL_TRIG
+	var hw dma_channel_hw_t
+	var order []string
+	record := func(name string, r *register32) {
+		register_fakes[r] = register_fake{set: func(v uint32) {
+			order = append(order, name)
+			r.Reg = v
+		}}
+	}
+	record("read", &hw.read_addr)
+	record("write", &hw.write_addr)
+	record("count", &hw.transfer_count)
+	record("ctrl_trig", &hw.ctrl_trig)
+	record("al1_ctrl", &hw.al1_ctrl)
+	defer func() {
+		for _, r := range []*register32{&hw.read_addr, &hw.write_addr, &hw.transfer_count, &hw.ctrl_trig, &hw.al1_ctrl} {
+			delete(register_fakes, r)
+		}
+	}()
+
+	c := DMA_channel_get_default_config(0)
+	dma_channel_configure(&hw, c, 0x2000, 0x1000, 16, false)
+	dma_channel_configure(&hw, c, 0x2000, 0x1000, 16, true)
+	want := []string{"read", "write", "count", "al1_ctrl", "read", "write", "count", "ctrl_trig"}
+	if len(order) != len(want) {
+		t.Fatalf("Register writes %v, expected %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Register writes %v, expected %v", order, want)
+		}
+	}
+	if hw.read_addr.Reg != 0x1000 || hw.write_addr.Reg != 0x2000 || hw.transfer_count.Reg != 16 || hw.ctrl_trig.Reg != 0x001F8019 {
+		t.Error("Unexpected register values")
+	}
+}
+
+func Test_DMA_Regs_005(t *testing.T) {
+	// Busy and wait for finish with a timeout
+	var hw dma_channel_hw_t
+	hw.ctrl_trig.Reg = _DMA_CTRL_BUSY | _DMA_CTRL_EN
+	if !dma_channel_is_busy(&hw) {
+		t.Error("Expected busy")
+	}
+	if dma_channel_wait_for_finish(&hw, time.Millisecond) {
+		t.Error("Expected timeout")
+	}
+	polls := 0
+	register_fakes[&hw.ctrl_trig] = register_fake{get: func() uint32 {
+		if polls++; polls > 3 {
+			return _DMA_CTRL_EN
+		}
+		return _DMA_CTRL_BUSY | _DMA_CTRL_EN
+	}}
+	defer delete(register_fakes, &hw.ctrl_trig)
+	if !dma_channel_wait_for_finish(&hw, 0) {
+		t.Error("Expected finish")
+	}
+}
+
+func Test_DMA_Regs_006(t *testing.T) {
+	// Abort disables the channel interrupt, waits for the abort, clears the
+	// interrupt and then restores the enables
+	var hw dma_hw_t
+	hw.irq_ctrl[0].inte.Reg = 0x00F
+	hw.irq_ctrl[1].inte.Reg = 0x100
+	var inte, ints []uint32
+	polls := 0
+	register_fakes[&hw.abort] = register_fake{
+		get: func() uint32 {
+			if polls++; polls > 2 {
+				return 0
+			}
+			return hw.abort.Reg
+		},
+		set: func(v uint32) {
+			inte = append(inte, hw.irq_ctrl[0].inte.Reg, hw.irq_ctrl[1].inte.Reg)
+			hw.abort.Reg = v
+		},
+	}
+	register_fakes[&hw.irq_ctrl[0].ints] = register_fake{set: func(v uint32) { ints = append(ints, v) }}
+	register_fakes[&hw.irq_ctrl[1].ints] = register_fake{set: func(v uint32) { ints = append(ints, v) }}
+	defer func() {
+		delete(register_fakes, &hw.abort)
+		delete(register_fakes, &hw.irq_ctrl[0].ints)
+		delete(register_fakes, &hw.irq_ctrl[1].ints)
+	}()
+
+	dma_channel_abort(&hw, 2)
+	if len(inte) != 2 || inte[0] != 0x00B || inte[1] != 0x100 {
+		t.Errorf("Interrupt enables during abort %X", inte)
+	}
+	if polls < 3 {
+		t.Error("Expected abort to be polled until complete")
+	}
+	if len(ints) != 2 || ints[0] != 0x4 || ints[1] != 0x4 {
+		t.Errorf("Interrupt clears %X", ints)
+	}
+	if hw.irq_ctrl[0].inte.Reg != 0x00F || hw.irq_ctrl[1].inte.Reg != 0x100 {
+		t.Error("Interrupt enables not restored")
+	}
+}
+
+func Test_DMA_Regs_007(t *testing.T) {
+	// Dispatch acknowledges each pending channel before calling the callback
+	var hw dma_irq_ctrl_hw_t
+	hw.ints.Reg = 0x821
+	register_fakes[&hw.ints] = register_fake{set: func(v uint32) { hw.ints.Reg &^= v }}
+	defer delete(register_fakes, &hw.ints)
+	var channels []uint32
+	dma_irqn_dispatch(&hw, nil, func(channel uint32) {
+		if hw.ints.Reg&(1<<channel) != 0 {
+			t.Errorf("Channel %d not acknowledged before callback", channel)
+		}
+		channels = append(channels, channel)
+	})
+	if len(channels) != 3 || channels[0] != 0 || channels[1] != 5 || channels[2] != 11 {
+		t.Errorf("Callback channels %v", channels)
+	}
+	if hw.ints.Reg != 0 {
+		t.Errorf("Pending interrupts 0x%X", hw.ints.Reg)
+	}
+
+	// Nil callback still acknowledges
+	hw.ints.Reg = 0x3
+	dma_irqn_dispatch(&hw, nil, nil)
+	if hw.ints.Reg != 0 {
+		t.Errorf("Pending interrupts 0x%X", hw.ints.Reg)
+	}
+
+	// Enable and disable a mask of channels
+	dma_irqn_set_channel_mask_enabled(&hw, 0x30, true)
+	dma_irqn_set_channel_mask_enabled(&hw, 0x10, false)
+	if hw.inte.Reg != 0x20 {
+		t.Errorf("Interrupt enables 0x%X", hw.inte.Reg)
+	}
+}
+
+func Test_DMA_Regs_008(t *testing.T) {
+	// Claim channels and timers
+	var claimed uint32
+	if !dma_claim(&claimed, 1) {
+		t.Error("Expected claim of 1")
+	}
+	if dma_claim(&claimed, 1) {
+		t.Error("Expected duplicate claim to fail")
+	}
+	for want := uint32(0); want < NUM_DMA_TIMERS; want++ {
+		if want == 1 {
+			continue
+		}
+		if num, ok := dma_claim_unused(&claimed, NUM_DMA_TIMERS); !ok || num != want {
+			t.Errorf("dma_claim_unused = %d, %v, expected %d", num, ok, want)
+		}
+	}
+	if _, ok := dma_claim_unused(&claimed, NUM_DMA_TIMERS); ok {
+		t.Error("Expected all timers to be claimed")
+	}
+	if v := dma_timer_fraction(6, 15625); v != 0x00063D09 {
+		t.Errorf("dma_timer_fraction = 0x%08X", v)
+	}
+}
+
+func Test_DMA_Regs_009(t *testing.T) {
+	// A callback for a channel is called in place of the shared callback
+	var hw dma_irq_ctrl_hw_t
+	register_fakes[&hw.ints] = register_fake{set: func(v uint32) { hw.ints.Reg &^= v }}
+	defer delete(register_fakes, &hw.ints)
+	var shared, own []uint32
+	var channels [NUM_DMA_CHANNELS]func(channel uint32)
+	channels[5] = func(channel uint32) {
+		own = append(own, channel)
+	}
+	hw.ints.Reg = 1<<0 | 1<<5 | 1<<7
+	dma_irqn_dispatch(&hw, &channels, func(channel uint32) {
+		shared = append(shared, channel)
+	})
+	if len(own) != 1 || own[0] != 5 {
+		t.Errorf("Channel callback channels %v", own)
+	}
+	if len(shared) != 2 || shared[0] != 0 || shared[1] != 7 {
+		t.Errorf("Shared callback channels %v", shared)
+	}
+
+	// Channels with their own callback are acknowledged without a shared
+	// callback
+	hw.ints.Reg = 1 << 5
+	dma_irqn_dispatch(&hw, &channels, nil)
+	if len(own) != 2 || hw.ints.Reg != 0 {
+		t.Errorf("Channel callback channels %v, pending interrupts 0x%X", own, hw.ints.Reg)
+	}
+}
@@ -0,0 +1,72 @@
+package sdk
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	DMA_TIMER_MAX_Y = 0xFFFF // Maximum denominator of a DMA timer fraction
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the numerator x and denominator y for a DMA timer which requests a
+// transfer rate times per second given the system clock frequency freq, and
+// the achieved rate. The fraction x/y is the closest to rate/freq with y no
+// more than 0xFFFF. Rates at or above freq return 1/1, and rates which are
+// too low are clamped to the minimum rate. A rate of zero returns zero
+// values.
+func DMA_timer_solve(freq, rate uint32) (uint16, uint16, uint32) {
+	if freq == 0 || rate == 0 {
+		return 0, 0, 0
+	} else if rate >= freq {
+		return 1, 1, freq
+	}
+
+	// Find the convergents of the continued fraction of rate/freq, stopping
+	// when the denominator is too large
+	h2, k2, h1, k1 := uint64(0), uint64(1), uint64(1), uint64(0)
+	for n, d := uint64(rate), uint64(freq); d != 0; n, d = d, n%d {
+		a := n / d
+		if a*k1+k2 > DMA_TIMER_MAX_Y {
+			// The best approximation is the last convergent or the largest
+			// semiconvergent which fits
+			s := (DMA_TIMER_MAX_Y - k2) / k1
+			hs, ks := s*h1+h2, s*k1+k2
+			if dma_timer_error(freq, rate, hs, ks) < dma_timer_error(freq, rate, h1, k1) {
+				h1, k1 = hs, ks
+			}
+			break
+		}
+		h2, k2, h1, k1 = h1, k1, a*h1+h2, a*k1+k2
+	}
+
+	// Clamp to the minimum rate
+	if h1 == 0 {
+		h1, k1 = 1, DMA_TIMER_MAX_Y
+	}
+
+	// Return the fraction and the rate we were able to achieve
+	return uint16(h1), uint16(k1), DMA_timer_rate_for(freq, uint16(h1), uint16(k1))
+}
+
+// Return the transfer rate for a DMA timer fraction x/y given the system
+// clock frequency freq
+func DMA_timer_rate_for(freq uint32, x, y uint16) uint32 {
+	if y == 0 {
+		return 0
+	}
+	return uint32(uint64(freq) * uint64(x) / uint64(y))
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Return the error between x/y and rate/freq, scaled by freq*y
+func dma_timer_error(freq, rate uint32, x, y uint64) uint64 {
+	a, b := x*uint64(freq), uint64(rate)*y
+	if a > b {
+		return (a - b) * DMA_TIMER_MAX_Y / y
+	}
+	return (b - a) * DMA_TIMER_MAX_Y / y
+}
@@ -0,0 +1,79 @@
+package sdk_test
+
+import (
+	"math"
+	"testing"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+func Test_DMA_Timer_001(t *testing.T) {
+	// Rates which are an exact fraction of the clock
+	tests := []struct {
+		freq, rate uint32
+		x, y       uint16
+	}{
+		{125_000_000, 1_000_000, 1, 125},
+		{125_000_000, 48_000, 6, 15625},
+		{125_000_000, 62_500_000, 1, 2},
+		{125_000_000, 125_000_000, 1, 1},
+		{125_000_000, 200_000_000, 1, 1},
+		{48_000_000, 48_000, 1, 1000},
+	}
+	for _, test := range tests {
+		x, y, achieved := DMA_timer_solve(test.freq, test.rate)
+		if x != test.x || y != test.y {
+			t.Errorf("DMA_timer_solve(%d, %d) = %d/%d, expected %d/%d", test.freq, test.rate, x, y, test.x, test.y)
+		}
+		if rate := DMA_timer_rate_for(test.freq, x, y); rate != achieved {
+			t.Errorf("DMA_timer_rate_for(%d, %d, %d) = %d, expected %d", test.freq, x, y, rate, achieved)
+		}
+	}
+}
+
+func Test_DMA_Timer_002(t *testing.T) {
+	// Rates which are approximated are the closest fraction, compared with a
+	// search of every denominator
+	tests := []struct {
+		freq, rate uint32
+	}{
+		{125_000_000, 44_100},
+		{125_000_000, 1_234_567},
+		{133_000_000, 48_000},
+		{125_000_000, 3_000},
+		{125_000_000, 99_999_999},
+	}
+	for _, test := range tests {
+		x, y, _ := DMA_timer_solve(test.freq, test.rate)
+		if x > y || y == 0 {
+			t.Errorf("DMA_timer_solve(%d, %d) = %d/%d, invalid fraction", test.freq, test.rate, x, y)
+			continue
+		}
+		ratio := float64(test.rate) / float64(test.freq)
+		best := math.Inf(1)
+		for d := 1; d <= DMA_TIMER_MAX_Y; d++ {
+			n := math.Round(ratio * float64(d))
+			if n < 1 || n > float64(d) {
+				continue
+			}
+			best = math.Min(best, math.Abs(n/float64(d)-ratio))
+		}
+		if e := math.Abs(float64(x)/float64(y) - ratio); e > best*(1+1e-9) {
+			t.Errorf("DMA_timer_solve(%d, %d) = %d/%d, error %g, expected %g", test.freq, test.rate, x, y, e, best)
+		}
+	}
+}
+
+func Test_DMA_Timer_003(t *testing.T) {
+	// Rates which are too low are clamped, and zero returns zero values
+	if x, y, achieved := DMA_timer_solve(125_000_000, 1); x != 1 || y != DMA_TIMER_MAX_Y || achieved != 1907 {
+		t.Errorf("DMA_timer_solve(125000000, 1) = %d/%d, %d", x, y, achieved)
+	}
+	if x, y, achieved := DMA_timer_solve(125_000_000, 0); x != 0 || y != 0 || achieved != 0 {
+		t.Errorf("DMA_timer_solve(125000000, 0) = %d/%d, %d", x, y, achieved)
+	}
+	if rate := DMA_timer_rate_for(125_000_000, 1, 0); rate != 0 {
+		t.Errorf("DMA_timer_rate_for(125000000, 1, 0) = %d", rate)
+	}
+}
@@ -1,4 +1,4 @@
-//go:build debug
+//go:build rp2040 && debug
 
 package sdk
 
@@ -1,11 +1,13 @@
+//go:build rp2040
+
 package sdk
 
 import (
+	"time"
 	"unsafe"
 
 	// Module imports
 	rp "device/rp"
-	volatile "runtime/volatile"
 )
 
 // SDK documentation
@@ -14,62 +16,30 @@ import (
 //////////////////////////////////////////////////////////////////////////////
 // TYPES
 
-type i2c_t struct {
-	con                volatile.Register32 // 0x0
-	tar                volatile.Register32 // 0x4
-	sar                volatile.Register32 // 0x8
-	_                  [4]byte
-	data_cmd           volatile.Register32 // 0x10
-	ss_scl_hcnt        volatile.Register32 // 0x14
-	ss_scl_lcnt        volatile.Register32 // 0x18
-	fs_scl_hcnt        volatile.Register32 // 0x1C
-	fs_scl_lcnt        volatile.Register32 // 0x20
-	_                  [8]byte
-	intr_stat          volatile.Register32 // 0x2C
-	intr_mask          volatile.Register32 // 0x30
-	raw_intr_stat      volatile.Register32 // 0x34
-	rx_tl              volatile.Register32 // 0x38
-	tx_tl              volatile.Register32 // 0x3C
-	CLR_INTR           volatile.Register32 // 0x40
-	CLR_RX_UNDER       volatile.Register32 // 0x44
-	CLR_RX_OVER        volatile.Register32 // 0x48
-	CLR_TX_OVER        volatile.Register32 // 0x4C
-	CLR_RD_REQ         volatile.Register32 // 0x50
-	CLR_TX_ABRT        volatile.Register32 // 0x54
-	CLR_RX_DONE        volatile.Register32 // 0x58
-	CLR_ACTIVITY       volatile.Register32 // 0x5C
-	CLR_STOP_DET       volatile.Register32 // 0x60
-	CLR_START_DET      volatile.Register32 // 0x64
-	CLR_GEN_CALL       volatile.Register32 // 0x68
-	enable             volatile.Register32 // 0x6C
-	status             volatile.Register32 // 0x70
-	TXFLR              volatile.Register32 // 0x74
-	RXFLR              volatile.Register32 // 0x78
-	SDA_HOLD           volatile.Register32 // 0x7C
-	TX_ABRT_SOURCE     volatile.Register32 // 0x80
-	SLV_DATA_NACK_ONLY volatile.Register32 // 0x84
-	dma_cr             volatile.Register32 // 0x88
-	DMA_TDLR           volatile.Register32 // 0x8C
-	DMA_RDLR           volatile.Register32 // 0x90
-	SDA_SETUP          volatile.Register32 // 0x94
-	ACK_GENERAL_CALL   volatile.Register32 // 0x98
-	ENABLE_STATUS      volatile.Register32 // 0x9C
-	FS_SPKLEN          volatile.Register32 // 0xA0
-	_                  [4]byte
-	CLR_RESTART_DET    volatile.Register32 // 0xA8
-	_                  [72]byte
-	COMP_PARAM_1       volatile.Register32 // 0xF4
-	COMP_VERSION       volatile.Register32 // 0xF8
-	COMP_TYPE          volatile.Register32 // 0xFC
-}
-
-type i2c_groups_t [NUM_I2CS]i2c_t
+type (
+	I2C_irq_t uint32
+)
 
 //////////////////////////////////////////////////////////////////////////////
 // CONSTANTS
 
+const (
+	I2C_IRQ_RX_FULL     I2C_irq_t = rp.I2C0_IC_INTR_MASK_M_RX_FULL     // RX FIFO at or above threshold
+	I2C_IRQ_RD_REQ      I2C_irq_t = rp.I2C0_IC_INTR_MASK_M_RD_REQ      // Slave addressed for a read
+	I2C_IRQ_TX_ABRT     I2C_irq_t = rp.I2C0_IC_INTR_MASK_M_TX_ABRT     // Transmit aborted
+	I2C_IRQ_STOP_DET    I2C_irq_t = rp.I2C0_IC_INTR_MASK_M_STOP_DET    // STOP condition on the bus
+	I2C_IRQ_START_DET   I2C_irq_t = rp.I2C0_IC_INTR_MASK_M_START_DET   // START or RESTART condition on the bus
+	I2C_IRQ_RESTART_DET I2C_irq_t = rp.I2C0_IC_INTR_MASK_M_RESTART_DET // RESTART condition when addressed as slave
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
 var (
-	i2c_groups = [NUM_I2CS](*i2c_t){(*i2c_t)(unsafe.Pointer(rp.I2C0)), (*i2c_t)(unsafe.Pointer(rp.I2C1))}
+	i2c_inst = [NUM_I2CS]i2c_inst_t{
+		{hw: (*i2c_hw_t)(unsafe.Pointer(rp.I2C0))},
+		{hw: (*i2c_hw_t)(unsafe.Pointer(rp.I2C1))},
+	}
 )
 
 //////////////////////////////////////////////////////////////////////////////
@@ -83,10 +53,12 @@ func I2C_init(inst, baudrate uint32) uint32 {
 	I2C_unreset(inst)
 
 	// Disable before config
-	i2c_groups[inst].enable.Set(0)
+	i2c_inst[inst].hw.enable.Set(0)
+	i2c_inst[inst].restart_on_next = false
 
-	// Configure as a fast-mode master with RepStart support, 7-bit addresses
-	i2c_groups[inst].con.Set(
+	// Configure as a fast-mode master with RepStart support, 7-bit addresses.
+	// The speed mode is then set from the baud rate.
+	i2c_inst[inst].hw.con.Set(
 		rp.I2C0_IC_CON_SPEED_FAST<<rp.I2C0_IC_CON_SPEED_Pos |
 			rp.I2C0_IC_CON_MASTER_MODE_ENABLED<<rp.I2C0_IC_CON_MASTER_MODE_Pos |
 			rp.I2C0_IC_CON_IC_SLAVE_DISABLE_SLAVE_DISABLED<<rp.I2C0_IC_CON_IC_SLAVE_DISABLE_Pos |
@@ -94,18 +66,16 @@ func I2C_init(inst, baudrate uint32) uint32 {
 			rp.I2C0_IC_CON_TX_EMPTY_CTRL_ENABLED<<rp.I2C0_IC_CON_TX_EMPTY_CTRL_Pos)
 
 	// Set FIFO watermarks to 1 to make things simpler. This is encoded by a register value of 0.
-	i2c_groups[inst].tx_tl.Set(0)
-	i2c_groups[inst].rx_tl.Set(0)
+	i2c_inst[inst].hw.tx_tl.Set(0)
+	i2c_inst[inst].hw.rx_tl.Set(0)
 
 	// Always enable the DREQ signalling -- harmless if DMA isn't listening
-	i2c_groups[inst].dma_cr.Set(
+	i2c_inst[inst].hw.dma_cr.Set(
 		rp.I2C0_IC_DMA_CR_TDMAE_ENABLED<<rp.I2C0_IC_DMA_CR_TDMAE_Pos |
 			rp.I2C0_IC_DMA_CR_RDMAE_ENABLED<<rp.I2C0_IC_DMA_CR_RDMAE_Pos)
 
 	// Re-sets i2c->hw->enable upon returning
-	//TODO
-	// return I2C_set_baudrate(inst, baudrate)
-	return 0
+	return I2C_set_baudrate(inst, baudrate)
 }
 
 // Disable the I2C HW block
@@ -139,45 +109,166 @@ func I2C_unreset(inst uint32) {
 	}
 }
 
-// Addresses of the form 000 0xxx or 111 1xxx are reserved. No slave should
-// have these addresses.
-//
-func I2C_reserved_addr(addr uint8) bool {
-	return (addr&0x78) == 0 || (addr&0x78) == 0x78
-}
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
 
-/*
 // Determine the I2C instance that is attached to the specified GPIO
-// pins for SDA and SCL
+// pins for SDA and SCL. SDA is on even pins and SCL on odd pins, and
+// the instance alternates every two pins.
 //
-//go:inline
 func I2C_gpio_to_inst(sda, scl GPIO_pin) (uint32, bool) {
-	// TODO
-	return 0, false
+	if sda >= NUM_BANK0_GPIOS || scl >= NUM_BANK0_GPIOS {
+		return 0, false
+	}
+	if sda&1 != 0 || scl&1 != 1 {
+		return 0, false
+	}
+	inst := uint32(sda>>1) & 1
+	if uint32(scl>>1)&1 != inst {
+		return 0, false
+	}
+	return inst, true
 }
 
-// Set I2C baudrate
+// Set I2C baudrate, and return the actual baudrate set, or zero if the
+// baudrate cannot be achieved with the current system clock
 //
 func I2C_set_baudrate(inst, baudrate uint32) uint32 {
-	// TODO
+	assert(inst < NUM_I2CS)
+	timing, ok := I2C_baudrate_solve(CLOCK_get_hz(CLOCK_sys), baudrate)
+	if !ok {
+		return 0
+	}
+	return i2c_set_timing(i2c_inst[inst].hw, timing)
+}
+
+// Set I2C port to slave mode with an address, or back to master mode. The
+// address is 7-bit or 10-bit depending on the address mode.
+//
+func I2C_set_slave_mode(inst uint32, slave bool, addr uint16) {
+	assert(inst < NUM_I2CS)
+	i2c_set_slave_mode(i2c_inst[inst].hw, slave, addr)
 }
 
-// Set I2C port to slave mode
+// Set 7-bit or 10-bit addresses. The Pico SDK only supports 7-bit
+// addresses, which is the mode set by I2C_init.
 //
-func I2C_set_slave_mode(inst uint32, slave bool, addr uint8) {
+func I2C_set_addr_mode(inst uint32, tenbit bool) {
+	assert(inst < NUM_I2CS)
+	i2c_set_addr_mode(i2c_inst[inst].hw, tenbit)
+}
 
+// Set which I2C interrupts are enabled
+//
+//go:inline
+func I2C_set_irq_mask(inst uint32, mask I2C_irq_t) {
+	assert(inst < NUM_I2CS)
+	i2c_inst[inst].hw.intr_mask.Set(uint32(mask))
 }
 
-// Attempt to write specified number of bytes to address, blocking until the
-// specified absolute time is reached.
+// Return which enabled I2C interrupts are pending
 //
-func i2c_write_blocking_until(inst uint32, addr uint8, w []byte, nostop bool, until time.Duration) {
-	// TODO
+//go:inline
+func I2C_get_irq_status(inst uint32) I2C_irq_t {
+	assert(inst < NUM_I2CS)
+	return I2C_irq_t(i2c_inst[inst].hw.intr_stat.Get())
 }
 
-// Attempt to read specified number of bytes from address, blocking until the
-// specified absolute time is reached.
-func i2c_read_blocking_until(inst uint32, addr uint8, r []byte, nostop bool, until time.Duration) {
-	// TODO
+// Clear I2C interrupts
+//
+// I2C_IRQ_RX_FULL cannot be cleared, it is cleared by reading the FIFO
+//
+func I2C_clear_irq(inst uint32, mask I2C_irq_t) {
+	assert(inst < NUM_I2CS)
+	hw := i2c_inst[inst].hw
+	if mask&I2C_IRQ_RD_REQ != 0 {
+		hw.clr_rd_req.Get()
+	}
+	if mask&I2C_IRQ_TX_ABRT != 0 {
+		hw.clr_tx_abrt.Get()
+	}
+	if mask&I2C_IRQ_STOP_DET != 0 {
+		hw.clr_stop_det.Get()
+	}
+	if mask&I2C_IRQ_START_DET != 0 {
+		hw.clr_start_det.Get()
+	}
+	if mask&I2C_IRQ_RESTART_DET != 0 {
+		hw.clr_restart_det.Get()
+	}
+}
+
+// Pop a byte from the RX FIFO, which should be readable
+//
+//go:inline
+func I2C_get_data(inst uint32) uint8 {
+	assert(inst < NUM_I2CS)
+	return uint8(i2c_inst[inst].hw.data_cmd.Get() & _I2C_IC_DATA_CMD_DAT_MSK)
+}
+
+// Push a byte to the TX FIFO, which should be writable
+//
+//go:inline
+func I2C_put_data(inst uint32, v uint8) {
+	assert(inst < NUM_I2CS)
+	i2c_inst[inst].hw.data_cmd.Set(uint32(v))
+}
+
+// Return the number of bytes in the RX FIFO
+//
+//go:inline
+func I2C_get_read_available(inst uint32) uint32 {
+	assert(inst < NUM_I2CS)
+	return i2c_inst[inst].hw.rxflr.Get()
+}
+
+// Return the space in the TX FIFO
+//
+//go:inline
+func I2C_get_write_available(inst uint32) uint32 {
+	assert(inst < NUM_I2CS)
+	return I2C_TX_BUFFER_DEPTH - i2c_inst[inst].hw.txflr.Get()
+}
+
+// Attempt to write bytes to an address, blocking until the absolute time
+// until is reached, or without a deadline if until is zero. If nostop is
+// true the bus is not released, and the next transfer begins with a restart.
+// Returns the number of bytes written, or ErrBadParameter if w is empty.
+//
+func I2C_write_blocking_until(inst uint32, addr uint16, w []byte, nostop bool, until time.Time) (int, error) {
+	assert(inst < NUM_I2CS)
+	return i2c_write_blocking_until(&i2c_inst[inst], addr, w, nostop, until)
+}
+
+// Attempt to read bytes from an address, blocking until the absolute time
+// until is reached, or without a deadline if until is zero. Returns the number
+// of bytes read, or ErrBadParameter if r is empty.
+//
+func I2C_read_blocking_until(inst uint32, addr uint16, r []byte, nostop bool, until time.Time) (int, error) {
+	assert(inst < NUM_I2CS)
+	return i2c_read_blocking_until(&i2c_inst[inst], addr, r, nostop, until)
+}
+
+// Attempt to write bytes to an address, with a timeout
+//
+func I2C_write_timeout(inst uint32, addr uint16, w []byte, nostop bool, timeout time.Duration) (int, error) {
+	return I2C_write_blocking_until(inst, addr, w, nostop, time.Now().Add(timeout))
+}
+
+// Attempt to read bytes from an address, with a timeout
+//
+func I2C_read_timeout(inst uint32, addr uint16, r []byte, nostop bool, timeout time.Duration) (int, error) {
+	return I2C_read_blocking_until(inst, addr, r, nostop, time.Now().Add(timeout))
+}
+
+// Write bytes to an address, blocking until complete
+//
+func I2C_write_blocking(inst uint32, addr uint16, w []byte, nostop bool) (int, error) {
+	return I2C_write_blocking_until(inst, addr, w, nostop, time.Time{})
+}
+
+// Read bytes from an address, blocking until complete
+//
+func I2C_read_blocking(inst uint32, addr uint16, r []byte, nostop bool) (int, error) {
+	return I2C_read_blocking_until(inst, addr, r, nostop, time.Time{})
 }
-*/
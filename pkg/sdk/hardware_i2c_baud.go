@@ -0,0 +1,94 @@
+package sdk
+
+// SDK documentation
+// https://github.com/raspberrypi/pico-sdk/blob/master/src/rp2_common/hardware_i2c/i2c.c
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// I2C_timing_t are the register values for a baud rate
+type I2C_timing_t struct {
+	Hcnt      uint32 // SCL high count, in clk_sys cycles
+	Lcnt      uint32 // SCL low count, in clk_sys cycles
+	Spklen    uint32 // Longest spike which is suppressed, in clk_sys cycles
+	SdaTxHold uint32 // SDA hold time after SCL falls, in clk_sys cycles
+	Baudrate  uint32 // Achieved baud rate
+	Speed     uint32 // Speed mode, I2C_SPEED_STANDARD or I2C_SPEED_FAST
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	I2C_MAX_SCL_COUNT  = 0xFFFF  // Maximum SCL high or low count
+	I2C_MIN_SCL_COUNT  = 8       // Minimum SCL high or low count
+	I2C_STANDARD_MODE  = 100000  // Maximum baud rate in standard mode
+	I2C_FAST_MODE      = 400000  // Maximum baud rate in fast mode
+	I2C_FAST_MODE_PLUS = 1000000 // Maximum baud rate in fast mode plus
+)
+
+const (
+	I2C_SPEED_STANDARD = 1 // Standard mode, which uses the SS SCL counts
+	I2C_SPEED_FAST     = 2 // Fast mode and fast mode plus, which use the FS SCL counts
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the register values for a baud rate, given the clk_sys frequency
+// freq_in. Returns false if the baud rate cannot be achieved, because the SCL
+// counts or hold time are out of range, or it is above fast mode plus.
+//
+// Unlike the Pico SDK, which always uses fast mode, standard mode is used for
+// baud rates up to I2C_STANDARD_MODE.
+func I2C_baudrate_solve(freq_in, baudrate uint32) (I2C_timing_t, bool) {
+	if baudrate == 0 || freq_in == 0 || baudrate > I2C_FAST_MODE_PLUS {
+		return I2C_timing_t{}, false
+	}
+
+	// The SCL low period is 60% of the total period, which meets the minimum
+	// low time in fast mode
+	period := (freq_in + baudrate/2) / baudrate
+	lcnt := period * 3 / 5
+	hcnt := period - lcnt
+
+	// Per I2C-bus specification a device in standard or fast mode must
+	// internally provide a hold time of at least 300ns for the SDA signal to
+	// bridge the undefined region of the falling edge of SCL. A smaller hold
+	// time of 120ns is used for fast mode plus. Reduce the fractions to avoid
+	// overflow, and add one to avoid truncation.
+	var sda_tx_hold uint32
+	if baudrate < I2C_FAST_MODE_PLUS {
+		sda_tx_hold = uint32(uint64(freq_in)*3/10_000_000) + 1
+	} else {
+		sda_tx_hold = uint32(uint64(freq_in)*3/25_000_000) + 1
+	}
+
+	// Suppress spikes of up to 1/16 of the low period
+	spklen := uint32(1)
+	if lcnt >= 16 {
+		spklen = lcnt / 16
+	}
+
+	// Check for out-of-range values
+	timing := I2C_timing_t{
+		Hcnt:      hcnt,
+		Lcnt:      lcnt,
+		Spklen:    spklen,
+		SdaTxHold: sda_tx_hold,
+		Baudrate:  freq_in / period,
+		Speed:     I2C_SPEED_FAST,
+	}
+	if baudrate <= I2C_STANDARD_MODE {
+		timing.Speed = I2C_SPEED_STANDARD
+	}
+	if hcnt > I2C_MAX_SCL_COUNT || lcnt > I2C_MAX_SCL_COUNT || hcnt < I2C_MIN_SCL_COUNT || lcnt < I2C_MIN_SCL_COUNT {
+		return timing, false
+	}
+	if sda_tx_hold > lcnt-2 {
+		return timing, false
+	}
+
+	// Return success
+	return timing, true
+}
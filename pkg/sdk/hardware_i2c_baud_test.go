@@ -0,0 +1,47 @@
+package sdk_test
+
+import (
+	"testing"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+func Test_I2C_Baud_001(t *testing.T) {
+	// Values which match the Pico SDK
+	tests := []struct {
+		freq, baud uint32
+		timing     I2C_timing_t
+	}{
+		{125_000_000, 100_000, I2C_timing_t{Hcnt: 500, Lcnt: 750, Spklen: 46, SdaTxHold: 38, Baudrate: 100_000, Speed: I2C_SPEED_STANDARD}},
+		{125_000_000, 400_000, I2C_timing_t{Hcnt: 126, Lcnt: 187, Spklen: 11, SdaTxHold: 38, Baudrate: 399_361, Speed: I2C_SPEED_FAST}},
+		{125_000_000, 1_000_000, I2C_timing_t{Hcnt: 50, Lcnt: 75, Spklen: 4, SdaTxHold: 16, Baudrate: 1_000_000, Speed: I2C_SPEED_FAST}},
+		{48_000_000, 100_000, I2C_timing_t{Hcnt: 192, Lcnt: 288, Spklen: 18, SdaTxHold: 15, Baudrate: 100_000, Speed: I2C_SPEED_STANDARD}},
+	}
+	for _, test := range tests {
+		timing, ok := I2C_baudrate_solve(test.freq, test.baud)
+		if !ok {
+			t.Errorf("I2C_baudrate_solve(%d, %d) failed", test.freq, test.baud)
+		} else if timing != test.timing {
+			t.Errorf("I2C_baudrate_solve(%d, %d) = %+v, expected %+v", test.freq, test.baud, timing, test.timing)
+		}
+	}
+}
+
+func Test_I2C_Baud_002(t *testing.T) {
+	// Baud rates which cannot be achieved
+	tests := []struct {
+		freq, baud uint32
+	}{
+		{12_000_000, 1_000_000}, // SCL counts too small
+		{125_000_000, 1_000},    // SCL counts too large
+		{125_000_000, 0},
+		{125_000_000, 3_400_000}, // High speed mode is not supported
+		{0, 100_000},
+	}
+	for _, test := range tests {
+		if _, ok := I2C_baudrate_solve(test.freq, test.baud); ok {
+			t.Errorf("I2C_baudrate_solve(%d, %d) expected to fail", test.freq, test.baud)
+		}
+	}
+}
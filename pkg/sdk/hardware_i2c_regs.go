@@ -0,0 +1,342 @@
+package sdk
+
+import (
+	"time"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// i2c_hw_t is the register block of a DesignWare I2C controller
+type i2c_hw_t struct {
+	con                register32 // 0x0
+	tar                register32 // 0x4
+	sar                register32 // 0x8
+	_                  [4]byte
+	data_cmd           register32 // 0x10
+	ss_scl_hcnt        register32 // 0x14
+	ss_scl_lcnt        register32 // 0x18
+	fs_scl_hcnt        register32 // 0x1C
+	fs_scl_lcnt        register32 // 0x20
+	_                  [8]byte
+	intr_stat          register32 // 0x2C
+	intr_mask          register32 // 0x30
+	raw_intr_stat      register32 // 0x34
+	rx_tl              register32 // 0x38
+	tx_tl              register32 // 0x3C
+	clr_intr           register32 // 0x40
+	clr_rx_under       register32 // 0x44
+	clr_rx_over        register32 // 0x48
+	clr_tx_over        register32 // 0x4C
+	clr_rd_req         register32 // 0x50
+	clr_tx_abrt        register32 // 0x54
+	clr_rx_done        register32 // 0x58
+	clr_activity       register32 // 0x5C
+	clr_stop_det       register32 // 0x60
+	clr_start_det      register32 // 0x64
+	clr_gen_call       register32 // 0x68
+	enable             register32 // 0x6C
+	status             register32 // 0x70
+	txflr              register32 // 0x74
+	rxflr              register32 // 0x78
+	sda_hold           register32 // 0x7C
+	tx_abrt_source     register32 // 0x80
+	slv_data_nack_only register32 // 0x84
+	dma_cr             register32 // 0x88
+	dma_tdlr           register32 // 0x8C
+	dma_rdlr           register32 // 0x90
+	sda_setup          register32 // 0x94
+	ack_general_call   register32 // 0x98
+	enable_status      register32 // 0x9C
+	fs_spklen          register32 // 0xA0
+	_                  [4]byte
+	clr_restart_det    register32 // 0xA8
+	_                  [72]byte
+	comp_param_1       register32 // 0xF4
+	comp_version       register32 // 0xF8
+	comp_type          register32 // 0xFC
+}
+
+// i2c_inst_t is an I2C instance, which remembers whether the next transfer
+// should begin with a restart
+type i2c_inst_t struct {
+	hw              *i2c_hw_t
+	restart_on_next bool
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	I2C_TX_BUFFER_DEPTH = 16 // Depth of the TX FIFO
+	I2C_RX_BUFFER_DEPTH = 16 // Depth of the RX FIFO
+)
+
+// Register bits, which are the same as in device/rp but are repeated here
+// so that they can be tested on the host
+const (
+	_I2C_IC_CON_MASTER_MODE           = 1 << 0
+	_I2C_IC_CON_SPEED_POS             = 1
+	_I2C_IC_CON_SPEED_MSK             = 3 << 1
+	_I2C_IC_CON_10BITADDR_SLAVE       = 1 << 3
+	_I2C_IC_CON_10BITADDR_MASTER      = 1 << 4
+	_I2C_IC_CON_IC_RESTART_EN         = 1 << 5
+	_I2C_IC_CON_IC_SLAVE_DISABLE      = 1 << 6
+	_I2C_IC_CON_TX_EMPTY_CTRL         = 1 << 8
+	_I2C_IC_CON_RX_FIFO_FULL_HLD_CTRL = 1 << 9
+	_I2C_IC_DATA_CMD_DAT_MSK          = 0xFF
+	_I2C_IC_DATA_CMD_CMD              = 1 << 8
+	_I2C_IC_DATA_CMD_STOP             = 1 << 9
+	_I2C_IC_DATA_CMD_RESTART          = 1 << 10
+	_I2C_IC_RAW_INTR_STAT_TX_EMPTY    = 1 << 4
+	_I2C_IC_RAW_INTR_STAT_STOP_DET    = 1 << 9
+	_I2C_IC_SDA_HOLD_TX_HOLD_MSK      = 0xFFFF
+	_I2C_IC_TAR_MSK                   = 0x3FF
+	_I2C_ABRT_7B_ADDR_NOACK           = 1 << 0
+	_I2C_ABRT_10ADDR1_NOACK           = 1 << 1
+	_I2C_ABRT_10ADDR2_NOACK           = 1 << 2
+	_I2C_ABRT_TXDATA_NOACK            = 1 << 3
+	_I2C_ABRT_GCALL_NOACK             = 1 << 4
+	_I2C_ABRT_ARB_LOST                = 1 << 12
+	_I2C_ABRT_SLV_ARBLOST             = 1 << 14
+	_I2C_ABRT_SOURCE_MSK              = 0x1FFFF // Excludes TX_FLUSH_CNT
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the error for the TX_ABRT_SOURCE register, or ErrSuccess if the
+// transfer was not aborted. When there are several reasons, an address NACK
+// takes priority over a data NACK, which takes priority over lost arbitration.
+func I2C_abort_reason(source uint32) Error {
+	switch {
+	case source&_I2C_ABRT_SOURCE_MSK == 0:
+		return ErrSuccess
+	case source&(_I2C_ABRT_7B_ADDR_NOACK|_I2C_ABRT_10ADDR1_NOACK|_I2C_ABRT_10ADDR2_NOACK|_I2C_ABRT_GCALL_NOACK) != 0:
+		return ErrAddrNack
+	case source&_I2C_ABRT_TXDATA_NOACK != 0:
+		return ErrDataNack
+	case source&(_I2C_ABRT_ARB_LOST|_I2C_ABRT_SLV_ARBLOST) != 0:
+		return ErrArbitrationLost
+	default:
+		return ErrAborted
+	}
+}
+
+// Addresses of the form 000 0xxx or 111 1xxx are reserved. No slave should
+// have these addresses.
+func I2C_reserved_addr(addr uint8) bool {
+	return (addr&0x78) == 0 || (addr&0x78) == 0x78
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Set the timing registers, and return the achieved rate
+func i2c_set_timing(hw *i2c_hw_t, timing I2C_timing_t) uint32 {
+	hw.enable.Set(0)
+
+	// Standard mode uses the SS counts, and fast mode and fast mode plus use
+	// the FS counts. The spike length applies to both.
+	hw.con.ReplaceBits(timing.Speed<<_I2C_IC_CON_SPEED_POS, _I2C_IC_CON_SPEED_MSK, 0)
+	if timing.Speed == I2C_SPEED_STANDARD {
+		hw.ss_scl_hcnt.Set(timing.Hcnt)
+		hw.ss_scl_lcnt.Set(timing.Lcnt)
+	} else {
+		hw.fs_scl_hcnt.Set(timing.Hcnt)
+		hw.fs_scl_lcnt.Set(timing.Lcnt)
+	}
+	hw.fs_spklen.Set(timing.Spklen)
+	hw.sda_hold.ReplaceBits(timing.SdaTxHold, _I2C_IC_SDA_HOLD_TX_HOLD_MSK, 0)
+
+	hw.enable.Set(1)
+	return timing.Baudrate
+}
+
+// Return true if the deadline has passed. A zero deadline never passes.
+func i2c_timeout(until time.Time) bool {
+	return !until.IsZero() && time.Now().After(until)
+}
+
+// Set the target address, which is 7-bit or 10-bit depending on the address
+// mode
+func i2c_set_target(hw *i2c_hw_t, addr uint16) {
+	hw.enable.Set(0)
+	hw.tar.Set(uint32(addr) & _I2C_IC_TAR_MSK)
+	hw.enable.Set(1)
+}
+
+// Set 7-bit or 10-bit addresses, for both the target address in master mode
+// and the slave address in slave mode
+func i2c_set_addr_mode(hw *i2c_hw_t, tenbit bool) {
+	hw.enable.Set(0)
+	if tenbit {
+		hw.con.SetBits(_I2C_IC_CON_10BITADDR_MASTER | _I2C_IC_CON_10BITADDR_SLAVE)
+	} else {
+		hw.con.ClearBits(_I2C_IC_CON_10BITADDR_MASTER | _I2C_IC_CON_10BITADDR_SLAVE)
+	}
+	hw.enable.Set(1)
+}
+
+// Return the data command for a byte of a transfer
+func i2c_data_cmd(i *i2c_inst_t, n, len int, nostop bool) uint32 {
+	var cmd uint32
+	if n == 0 && i.restart_on_next {
+		cmd |= _I2C_IC_DATA_CMD_RESTART
+	}
+	if n == len-1 && !nostop {
+		cmd |= _I2C_IC_DATA_CMD_STOP
+	}
+	return cmd
+}
+
+// Write bytes to an address, blocking until the deadline. Returns the number
+// of bytes written, which is less than len(w) if data was not acknowledged.
+// The hardware sends the start and stop conditions alongside data, so w
+// cannot be empty.
+func i2c_write_blocking_until(i *i2c_inst_t, addr uint16, w []byte, nostop bool, until time.Time) (int, error) {
+	if len(w) == 0 {
+		return 0, ErrBadParameter.With("I2C write: no data")
+	}
+	hw := i.hw
+	i2c_set_target(hw, addr)
+
+	abort, timeout := false, false
+	abort_reason := uint32(0)
+	n := 0
+	for ; n < len(w); n++ {
+		last := n == len(w)-1
+		hw.data_cmd.Set(i2c_data_cmd(i, n, len(w), nostop) | uint32(w[n]))
+
+		// Wait until the transmission of the address/data from the internal
+		// shift register has completed. For this to function correctly, the
+		// TX_EMPTY_CTRL flag in IC_CON must be set.
+		for !hw.raw_intr_stat.HasBits(_I2C_IC_RAW_INTR_STAT_TX_EMPTY) {
+			if timeout = i2c_timeout(until); timeout {
+				break
+			}
+		}
+
+		// If there was a timeout, don't attempt to do anything else
+		if !timeout {
+			// Clearing the abort flag also clears the reason
+			if abort_reason = hw.tx_abrt_source.Get(); abort_reason != 0 {
+				hw.clr_tx_abrt.Get()
+				abort = true
+			}
+
+			// If the transaction was aborted or if it completed successfully
+			// wait until the STOP condition has occured
+			if abort || (last && !nostop) {
+				for !hw.raw_intr_stat.HasBits(_I2C_IC_RAW_INTR_STAT_STOP_DET) {
+					if timeout = i2c_timeout(until); timeout {
+						break
+					}
+				}
+				if !timeout {
+					hw.clr_stop_det.Get()
+				}
+			}
+		}
+
+		// The hardware issues a STOP automatically on an abort condition
+		if abort || timeout {
+			break
+		}
+	}
+
+	// nostop means we are now at the end of a message but not the end of a
+	// transfer
+	i.restart_on_next = nostop
+
+	// Determine the result. When data is not acknowledged, n is the number of
+	// bytes acknowledged.
+	switch {
+	case timeout:
+		return n, ErrTimeout.Withf("I2C write: address 0x%03X", addr)
+	case abort:
+		if reason := I2C_abort_reason(abort_reason); reason == ErrDataNack {
+			return n, reason.Withf("I2C write: address 0x%03X", addr)
+		} else {
+			return 0, reason.Withf("I2C write: address 0x%03X", addr)
+		}
+	default:
+		return n, nil
+	}
+}
+
+// Read bytes from an address, blocking until the deadline. Returns the
+// number of bytes read.
+func i2c_read_blocking_until(i *i2c_inst_t, addr uint16, r []byte, nostop bool, until time.Time) (int, error) {
+	if len(r) == 0 {
+		return 0, ErrBadParameter.With("I2C read: no data")
+	}
+	hw := i.hw
+	i2c_set_target(hw, addr)
+
+	abort, timeout := false, false
+	abort_reason := uint32(0)
+	n := 0
+	for ; n < len(r); n++ {
+		for I2C_TX_BUFFER_DEPTH-hw.txflr.Get() == 0 {
+			if timeout = i2c_timeout(until); timeout {
+				break
+			}
+		}
+		if timeout {
+			break
+		}
+		hw.data_cmd.Set(i2c_data_cmd(i, n, len(r), nostop) | _I2C_IC_DATA_CMD_CMD)
+
+		// Wait for data, or an abort. Clearing the abort flag also clears the
+		// reason, and reads non-zero if there was an abort.
+		for {
+			abort_reason = hw.tx_abrt_source.Get()
+			abort = hw.clr_tx_abrt.Get() != 0
+			if abort || hw.rxflr.Get() != 0 {
+				break
+			}
+			if timeout = i2c_timeout(until); timeout {
+				break
+			}
+		}
+		if abort || timeout {
+			break
+		}
+		r[n] = byte(hw.data_cmd.Get() & _I2C_IC_DATA_CMD_DAT_MSK)
+	}
+
+	// nostop means we are now at the end of a message but not the end of a
+	// transfer
+	i.restart_on_next = nostop
+
+	// Determine the result. An abort without a reason is treated as the
+	// address not being acknowledged.
+	switch {
+	case timeout:
+		return n, ErrTimeout.Withf("I2C read: address 0x%03X", addr)
+	case abort && abort_reason&_I2C_ABRT_SOURCE_MSK == 0:
+		return 0, ErrAddrNack.Withf("I2C read: address 0x%03X", addr)
+	case abort:
+		return 0, I2C_abort_reason(abort_reason).Withf("I2C read: address 0x%03X", addr)
+	default:
+		return n, nil
+	}
+}
+
+// Set the controller to slave mode with an address, or back to master mode
+func i2c_set_slave_mode(hw *i2c_hw_t, slave bool, addr uint16) {
+	hw.enable.Set(0)
+	if slave {
+		hw.con.ClearBits(_I2C_IC_CON_MASTER_MODE | _I2C_IC_CON_IC_SLAVE_DISABLE)
+		hw.con.SetBits(_I2C_IC_CON_RX_FIFO_FULL_HLD_CTRL)
+		hw.sar.Set(uint32(addr) & _I2C_IC_TAR_MSK)
+	} else {
+		hw.con.SetBits(_I2C_IC_CON_MASTER_MODE | _I2C_IC_CON_IC_SLAVE_DISABLE)
+		hw.con.ClearBits(_I2C_IC_CON_RX_FIFO_FULL_HLD_CTRL)
+	}
+	hw.enable.Set(1)
+}
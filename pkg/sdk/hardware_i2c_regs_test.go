@@ -0,0 +1,289 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"unsafe"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+)
+
+// fake_i2c emulates a DesignWare I2C controller in master mode, connected to
+// a target which acknowledges a single address. Each data command completes
+// immediately; reads return incrementing bytes.
+type fake_i2c struct {
+	hw      i2c_hw_t
+	addr    uint32
+	nack    int // Number of data bytes acknowledged, or -1
+	cmds    []uint32
+	abort   uint32
+	stopped bool
+	rx      []uint32
+	next    byte
+}
+
+func new_fake_i2c(t *testing.T, addr uint8) *fake_i2c {
+	f := &fake_i2c{addr: uint32(addr), nack: -1}
+	register_fakes[&f.hw.data_cmd] = register_fake{
+		get: func() uint32 {
+			if len(f.rx) == 0 {
+				t.Fatal("Read from empty RX FIFO")
+			}
+			v := f.rx[0]
+			f.rx = f.rx[1:]
+			return v
+		},
+		set: func(v uint32) {
+			f.cmds = append(f.cmds, v)
+			if f.hw.tar.Get() != f.addr {
+				f.abort |= _I2C_ABRT_7B_ADDR_NOACK
+			} else if f.nack >= 0 && len(f.cmds) > f.nack {
+				f.abort |= _I2C_ABRT_TXDATA_NOACK
+			} else if v&_I2C_IC_DATA_CMD_CMD != 0 {
+				f.rx = append(f.rx, uint32(f.next))
+				f.next++
+			}
+			if f.abort != 0 || v&_I2C_IC_DATA_CMD_STOP != 0 {
+				f.stopped = true
+			}
+		},
+	}
+	register_fakes[&f.hw.raw_intr_stat] = register_fake{
+		get: func() uint32 {
+			v := uint32(_I2C_IC_RAW_INTR_STAT_TX_EMPTY)
+			if f.stopped {
+				v |= _I2C_IC_RAW_INTR_STAT_STOP_DET
+			}
+			return v
+		},
+	}
+	register_fakes[&f.hw.tx_abrt_source] = register_fake{
+		get: func() uint32 { return f.abort },
+	}
+	register_fakes[&f.hw.clr_tx_abrt] = register_fake{
+		get: func() uint32 {
+			v := f.abort
+			f.abort = 0
+			if v != 0 {
+				return 1
+			}
+			return 0
+		},
+	}
+	register_fakes[&f.hw.clr_stop_det] = register_fake{
+		get: func() uint32 {
+			f.stopped = false
+			return 0
+		},
+	}
+	register_fakes[&f.hw.rxflr] = register_fake{
+		get: func() uint32 { return uint32(len(f.rx)) },
+	}
+	t.Cleanup(func() {
+		delete(register_fakes, &f.hw.data_cmd)
+		delete(register_fakes, &f.hw.raw_intr_stat)
+		delete(register_fakes, &f.hw.tx_abrt_source)
+		delete(register_fakes, &f.hw.clr_tx_abrt)
+		delete(register_fakes, &f.hw.clr_stop_det)
+		delete(register_fakes, &f.hw.rxflr)
+	})
+	return f
+}
+
+func Test_I2C_Regs_001(t *testing.T) {
+	// Check the register layout matches the datasheet
+	var hw i2c_hw_t
+	if size := unsafe.Sizeof(hw); size != 0x100 {
+		t.Errorf("Unexpected size 0x%X", size)
+	}
+	offsets := []struct {
+		name   string
+		offset uintptr
+		want   uintptr
+	}{
+		{"data_cmd", unsafe.Offsetof(hw.data_cmd), 0x10},
+		{"fs_scl_hcnt", unsafe.Offsetof(hw.fs_scl_hcnt), 0x1C},
+		{"raw_intr_stat", unsafe.Offsetof(hw.raw_intr_stat), 0x34},
+		{"clr_tx_abrt", unsafe.Offsetof(hw.clr_tx_abrt), 0x54},
+		{"enable", unsafe.Offsetof(hw.enable), 0x6C},
+		{"sda_hold", unsafe.Offsetof(hw.sda_hold), 0x7C},
+		{"tx_abrt_source", unsafe.Offsetof(hw.tx_abrt_source), 0x80},
+		{"fs_spklen", unsafe.Offsetof(hw.fs_spklen), 0xA0},
+		{"clr_restart_det", unsafe.Offsetof(hw.clr_restart_det), 0xA8},
+		{"comp_type", unsafe.Offsetof(hw.comp_type), 0xFC},
+	}
+	for _, o := range offsets {
+		if o.offset != o.want {
+			t.Errorf("%s: offset 0x%X, expected 0x%X", o.name, o.offset, o.want)
+		}
+	}
+}
+
+func Test_I2C_Regs_002(t *testing.T) {
+	// Timing registers are written with the controller disabled
+	var hw i2c_hw_t
+	timing, _ := I2C_baudrate_solve(125_000_000, 400_000)
+	if baud := i2c_set_timing(&hw, timing); baud != timing.Baudrate {
+		t.Error("Unexpected baudrate", baud)
+	}
+	if hw.fs_scl_hcnt.Reg != 126 || hw.fs_scl_lcnt.Reg != 187 || hw.fs_spklen.Reg != 11 || hw.sda_hold.Reg != 38 {
+		t.Error("Unexpected timing registers")
+	}
+	if hw.con.Reg&_I2C_IC_CON_SPEED_MSK != I2C_SPEED_FAST<<_I2C_IC_CON_SPEED_POS {
+		t.Error("Unexpected speed")
+	}
+	if hw.enable.Reg != 1 {
+		t.Error("Expected controller to be enabled")
+	}
+}
+
+func Test_I2C_Regs_003(t *testing.T) {
+	// Write with stop, then a write with nostop followed by a restart
+	f := new_fake_i2c(t, 0x40)
+	i := &i2c_inst_t{hw: &f.hw}
+	if n, err := i2c_write_blocking_until(i, 0x40, []byte{1, 2, 3}, false, time.Time{}); err != nil {
+		t.Fatal(err)
+	} else if n != 3 {
+		t.Error("Unexpected count", n)
+	}
+	if f.cmds[0] != 1 || f.cmds[1] != 2 || f.cmds[2] != 3|_I2C_IC_DATA_CMD_STOP {
+		t.Errorf("Unexpected commands %03X", f.cmds)
+	}
+	f.cmds = nil
+	if _, err := i2c_write_blocking_until(i, 0x40, []byte{4}, true, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if !i.restart_on_next || f.cmds[0] != 4 {
+		t.Errorf("Unexpected commands %03X", f.cmds)
+	}
+	f.cmds = nil
+	r := make([]byte, 2)
+	if n, err := i2c_read_blocking_until(i, 0x40, r, false, time.Time{}); err != nil {
+		t.Fatal(err)
+	} else if n != 2 || r[0] != 0 || r[1] != 1 {
+		t.Error("Unexpected read", r[:n])
+	}
+	if f.cmds[0] != _I2C_IC_DATA_CMD_CMD|_I2C_IC_DATA_CMD_RESTART || f.cmds[1] != _I2C_IC_DATA_CMD_CMD|_I2C_IC_DATA_CMD_STOP {
+		t.Errorf("Unexpected commands %03X", f.cmds)
+	}
+	if i.restart_on_next {
+		t.Error("Expected restart_on_next to be cleared")
+	}
+}
+
+func Test_I2C_Regs_004(t *testing.T) {
+	// Address not acknowledged
+	f := new_fake_i2c(t, 0x40)
+	i := &i2c_inst_t{hw: &f.hw}
+	if _, err := i2c_write_blocking_until(i, 0x41, []byte{1, 2}, false, time.Time{}); !errors.Is(err, ErrAddrNack) {
+		t.Error("Unexpected error", err)
+	} else if len(f.cmds) != 1 {
+		t.Error("Expected transfer to stop after the address")
+	}
+	if _, err := i2c_read_blocking_until(i, 0x41, make([]byte, 2), false, time.Time{}); !errors.Is(err, ErrAddrNack) {
+		t.Error("Unexpected error", err)
+	}
+}
+
+func Test_I2C_Regs_005(t *testing.T) {
+	// A bus which never completes times out
+	var hw i2c_hw_t
+	i := &i2c_inst_t{hw: &hw}
+	start := time.Now()
+	if _, err := i2c_write_blocking_until(i, 0x40, []byte{1}, false, start.Add(10*time.Millisecond)); !errors.Is(err, ErrTimeout) {
+		t.Error("Unexpected error", err)
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Error("Returned before deadline")
+	}
+}
+
+func Test_I2C_Regs_006(t *testing.T) {
+	// Decode abort sources
+	tests := []struct {
+		source uint32
+		err    Error
+	}{
+		{0, ErrSuccess},
+		{0xFF800000, ErrSuccess}, // TX_FLUSH_CNT only
+		{_I2C_ABRT_7B_ADDR_NOACK, ErrAddrNack},
+		{_I2C_ABRT_10ADDR1_NOACK, ErrAddrNack},
+		{_I2C_ABRT_10ADDR2_NOACK, ErrAddrNack},
+		{_I2C_ABRT_GCALL_NOACK, ErrAddrNack},
+		{_I2C_ABRT_TXDATA_NOACK, ErrDataNack},
+		{_I2C_ABRT_TXDATA_NOACK | 1<<23, ErrDataNack},
+		{_I2C_ABRT_ARB_LOST, ErrArbitrationLost},
+		{_I2C_ABRT_SLV_ARBLOST, ErrArbitrationLost},
+		{_I2C_ABRT_ARB_LOST | _I2C_ABRT_7B_ADDR_NOACK, ErrAddrNack},
+		{_I2C_ABRT_ARB_LOST | _I2C_ABRT_TXDATA_NOACK, ErrDataNack},
+		{1 << 16, ErrAborted}, // ABRT_USER_ABRT
+		{1 << 11, ErrAborted}, // ABRT_MASTER_DIS
+	}
+	for _, test := range tests {
+		if err := I2C_abort_reason(test.source); err != test.err {
+			t.Errorf("I2C_abort_reason(0x%08X) = %v, expected %v", test.source, err, test.err)
+		}
+	}
+}
+
+func Test_I2C_Regs_007(t *testing.T) {
+	// Data not acknowledged returns the number of bytes acknowledged
+	f := new_fake_i2c(t, 0x40)
+	f.nack = 2
+	i := &i2c_inst_t{hw: &f.hw}
+	if n, err := i2c_write_blocking_until(i, 0x40, []byte{1, 2, 3, 4}, false, time.Time{}); !errors.Is(err, ErrDataNack) {
+		t.Error("Unexpected error", err)
+	} else if n != 2 {
+		t.Error("Unexpected count", n)
+	}
+}
+
+func Test_I2C_Regs_008(t *testing.T) {
+	// Standard mode uses the SS counts
+	var hw i2c_hw_t
+	timing, _ := I2C_baudrate_solve(125_000_000, 100_000)
+	i2c_set_timing(&hw, timing)
+	if hw.ss_scl_hcnt.Reg != 500 || hw.ss_scl_lcnt.Reg != 750 || hw.fs_scl_hcnt.Reg != 0 {
+		t.Error("Unexpected timing registers")
+	}
+	if hw.con.Reg&_I2C_IC_CON_SPEED_MSK != I2C_SPEED_STANDARD<<_I2C_IC_CON_SPEED_POS {
+		t.Error("Unexpected speed")
+	}
+}
+
+func Test_I2C_Regs_009(t *testing.T) {
+	// 10-bit addresses set both address mode bits, and the target address
+	// is masked to 10 bits
+	var hw i2c_hw_t
+	hw.con.Reg = _I2C_IC_CON_MASTER_MODE
+	i2c_set_addr_mode(&hw, true)
+	if hw.con.Reg != _I2C_IC_CON_MASTER_MODE|_I2C_IC_CON_10BITADDR_MASTER|_I2C_IC_CON_10BITADDR_SLAVE {
+		t.Errorf("Unexpected IC_CON 0x%X", hw.con.Reg)
+	}
+	i2c_set_target(&hw, 0x3A5|0x800)
+	if hw.tar.Reg != 0x3A5 {
+		t.Errorf("Unexpected IC_TAR 0x%X", hw.tar.Reg)
+	}
+	i2c_set_addr_mode(&hw, false)
+	if hw.con.Reg != _I2C_IC_CON_MASTER_MODE {
+		t.Errorf("Unexpected IC_CON 0x%X", hw.con.Reg)
+	}
+}
+
+func Test_I2C_Regs_010(t *testing.T) {
+	// Empty transfers are rejected without touching the hardware, as start
+	// and stop conditions are sent alongside data
+	f := new_fake_i2c(t, 0x40)
+	i := &i2c_inst_t{hw: &f.hw}
+	if _, err := i2c_write_blocking_until(i, 0x40, nil, false, time.Time{}); !errors.Is(err, ErrBadParameter) {
+		t.Error("Unexpected error", err)
+	}
+	if _, err := i2c_read_blocking_until(i, 0x40, []byte{}, false, time.Time{}); !errors.Is(err, ErrBadParameter) {
+		t.Error("Unexpected error", err)
+	}
+	if len(f.cmds) != 0 {
+		t.Error("Unexpected commands", f.cmds)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build rp2040
+
+package sdk
+
+import rp "device/rp"
+
+// Return the core number the call was made from
+//
+//go:inline
+func get_core_num() uint32 {
+	return rp.SIO.CPUID.Get()
+}
+
+// Return the cpu frequency in Hz
+//
+//go:inline
+func get_cpu_frequency() uint64 {
+	return uint64(CLOCK_get_hz(CLOCK_sys))
+}
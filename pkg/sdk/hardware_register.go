@@ -0,0 +1,14 @@
+//go:build rp2040
+
+package sdk
+
+import (
+	// Module imports
+	volatile "runtime/volatile"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// register32 is a memory-mapped hardware register
+type register32 = volatile.Register32
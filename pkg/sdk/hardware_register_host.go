@@ -0,0 +1,62 @@
+//go:build !rp2040
+
+package sdk
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// register32 is a fake hardware register for testing on the host. It has the
+// same size and methods as volatile.Register32, so register layouts can be
+// shared. Reads and writes can be intercepted with register_fakes, in order
+// to emulate the behaviour of the hardware.
+type register32 struct {
+	Reg uint32
+}
+
+// register_fake intercepts reads and writes of a register. Either function
+// can be nil, in which case the value is read or written directly.
+type register_fake struct {
+	get func() uint32
+	set func(uint32)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	register_fakes = map[*register32]register_fake{}
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (r *register32) Get() uint32 {
+	if fake, exists := register_fakes[r]; exists && fake.get != nil {
+		return fake.get()
+	}
+	return r.Reg
+}
+
+func (r *register32) Set(value uint32) {
+	if fake, exists := register_fakes[r]; exists && fake.set != nil {
+		fake.set(value)
+	} else {
+		r.Reg = value
+	}
+}
+
+func (r *register32) SetBits(value uint32) {
+	r.Set(r.Get() | value)
+}
+
+func (r *register32) ClearBits(value uint32) {
+	r.Set(r.Get() &^ value)
+}
+
+func (r *register32) HasBits(value uint32) bool {
+	return (r.Get() & value) > 0
+}
+
+func (r *register32) ReplaceBits(value uint32, mask uint32, pos uint8) {
+	r.Set(r.Get()&^(mask<<pos) | value<<pos)
+}
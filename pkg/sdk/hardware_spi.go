@@ -1,6 +1,10 @@
+//go:build rp2040
+
 package sdk
 
 import (
+	"unsafe"
+
 	// Module imports
 	rp "device/rp"
 )
@@ -15,6 +19,7 @@ type (
 	SPI_cpha_t  uint32
 	SPI_cpol_t  uint32
 	SPI_order_t uint32
+	SPI_irq_t   uint32
 )
 
 //////////////////////////////////////////////////////////////////////////////
@@ -36,11 +41,17 @@ const (
 )
 
 const (
-	_SPI_FIFO_DEPTH = 8
+	SPI_IRQ_RX_OVERRUN SPI_irq_t = rp.SPI0_SSPIMSC_RORIM // Frame received when the RX FIFO was full
+	SPI_IRQ_RX_TIMEOUT SPI_irq_t = rp.SPI0_SSPIMSC_RTIM  // RX FIFO not empty and no frames received for a period
+	SPI_IRQ_RX         SPI_irq_t = rp.SPI0_SSPIMSC_RXIM  // RX FIFO half full or more
+	SPI_IRQ_TX         SPI_irq_t = rp.SPI0_SSPIMSC_TXIM  // TX FIFO half empty or less
 )
 
 var (
-	spi_groups = [NUM_SPIS]*rp.SPI0_Type{rp.SPI0, rp.SPI1}
+	spi_groups = [NUM_SPIS]*spi_hw_t{
+		(*spi_hw_t)(unsafe.Pointer(rp.SPI0)),
+		(*spi_hw_t)(unsafe.Pointer(rp.SPI1)),
+	}
 )
 
 //////////////////////////////////////////////////////////////////////////////
@@ -74,7 +85,7 @@ func SPI_init(spi, baudrate uint32) uint32 {
 	assert(spi < NUM_SPIS)
 	SPI_reset(spi)
 	SPI_unreset(spi)
-	// TODO SPI_set_baudrate(spi, baudrate)
+	baudrate = SPI_set_baudrate(spi, baudrate)
 
 	// Always enable DREQ signals -- harmless if DMA is not listening
 	SPI_set_format(spi, 8, SPI_CPOL_0, SPI_CPHA_0, SPI_MSB_FIRST)
@@ -110,50 +121,113 @@ func SPI_set_format(spi uint32, data_bits uint8, cpol SPI_cpol_t, cpha SPI_cpha_
 	spi_groups[spi].SSPCR0.ReplaceBits(v, m, 0)
 }
 
-/*
 // Set SPI baudrate
 //
+// Set SPI frequency as close as possible to baudrate, without exceeding it,
+// and return the actual achieved rate. The rate is derived from clk_peri.
 func SPI_set_baudrate(spi, baudrate uint32) uint32 {
 	assert(spi < NUM_SPIS)
-	assert(baudrate <= clock_get_hz(CLOCK_PERI))
-
-	uint32 prescale, postdiv
-
-    // Find smallest prescale value which puts output frequency in range of
-    // post-divide. Prescale is an even number from 2 to 254 inclusive.
-    for (prescale = 2; prescale <= 254; prescale += 2) {
-        if (freq_in < (prescale + 2) * 256 * (uint64_t) baudrate)
-            break;
-    }
-    invalid_params_if(SPI, prescale > 254); // Frequency too low
+	assert(baudrate > 0)
+	prescale, postdiv, actual := SPI_baudrate_solve(CLOCK_get_hz(CLOCK_peri), baudrate)
+	spi_groups[spi].SSPCPSR.Set(prescale)
+	spi_groups[spi].SSPCR0.ReplaceBits((postdiv-1)<<rp.SPI0_SSPCR0_SCR_Pos, rp.SPI0_SSPCR0_SCR_Msk, 0)
 
-    // Find largest post-divide which makes output <= baudrate. Post-divide is
-    // an integer in the range 1 to 256 inclusive.
-    for (postdiv = 256; postdiv > 1; --postdiv) {
-        if (freq_in / (prescale * (postdiv - 1)) > baudrate)
-            break;
-    }
-
-    spi_get_hw(spi)->cpsr = prescale;
-    hw_write_masked(&spi_get_hw(spi)->cr0, (postdiv - 1) << SPI_SSPCR0_SCR_LSB, SPI_SSPCR0_SCR_BITS);
+	// Return the frequency we were able to achieve
+	return actual
+}
 
-    // Return the frequency we were able to achieve
-    return freq_in / (prescale * postdiv);
+// Get SPI baudrate
+//
+// Return the current baudrate, from the prescale and post-divide values
+func SPI_get_baudrate(spi uint32) uint32 {
+	assert(spi < NUM_SPIS)
+	prescale := spi_groups[spi].SSPCPSR.Get() & rp.SPI0_SSPCPSR_CPSDVSR_Msk
+	postdiv := (spi_groups[spi].SSPCR0.Get()&rp.SPI0_SSPCR0_SCR_Msk)>>rp.SPI0_SSPCR0_SCR_Pos + 1
+	return SPI_baudrate_for(CLOCK_get_hz(CLOCK_peri), prescale, postdiv)
 }
-*/
 
-/*
 // Set SPI master/slave
 //
-// By default, spi_init() sets master-mode
-//
+// By default, spi_init() sets master-mode. The SPI is disabled while the mode
+// is changed, as required by the PL022.
 func SPI_set_slave(spi uint32, slave bool) {
 	assert(spi < NUM_SPIS)
+	enabled := spi_groups[spi].SSPCR1.HasBits(rp.SPI0_SSPCR1_SSE)
+	spi_groups[spi].SSPCR1.ClearBits(rp.SPI0_SSPCR1_SSE)
 	if slave {
 		spi_groups[spi].SSPCR1.SetBits(rp.SPI0_SSPCR1_MS)
 	} else {
 		spi_groups[spi].SSPCR1.ClearBits(rp.SPI0_SSPCR1_MS)
 	}
+	if enabled {
+		spi_groups[spi].SSPCR1.SetBits(rp.SPI0_SSPCR1_SSE)
+	}
+}
+
+// Enable or disable the SPI
+//
+// SPI_init() enables the SPI. It should be disabled while the mode is set for
+// peripheral operation, so that it never runs as a controller.
+//
+//go:inline
+func SPI_set_enabled(spi uint32, enabled bool) {
+	assert(spi < NUM_SPIS)
+	if enabled {
+		spi_groups[spi].SSPCR1.SetBits(rp.SPI0_SSPCR1_SSE)
+	} else {
+		spi_groups[spi].SSPCR1.ClearBits(rp.SPI0_SSPCR1_SSE)
+	}
+}
+
+// Set which SPI interrupts are enabled
+//
+//go:inline
+func SPI_set_irq_mask(spi uint32, mask SPI_irq_t) {
+	assert(spi < NUM_SPIS)
+	spi_groups[spi].SSPIMSC.Set(uint32(mask))
+}
+
+// Return which SPI interrupts are enabled
+//
+//go:inline
+func SPI_get_irq_mask(spi uint32) SPI_irq_t {
+	assert(spi < NUM_SPIS)
+	return SPI_irq_t(spi_groups[spi].SSPIMSC.Get())
+}
+
+// Return which enabled SPI interrupts are pending
+//
+//go:inline
+func SPI_get_irq_status(spi uint32) SPI_irq_t {
+	assert(spi < NUM_SPIS)
+	return SPI_irq_t(spi_groups[spi].SSPMIS.Get())
+}
+
+// Clear SPI interrupts
+//
+// Only SPI_IRQ_RX_OVERRUN and SPI_IRQ_RX_TIMEOUT can be cleared, the FIFO
+// interrupts are cleared by reading or writing the FIFO
+//
+//go:inline
+func SPI_clear_irq(spi uint32, mask SPI_irq_t) {
+	assert(spi < NUM_SPIS)
+	spi_groups[spi].SSPICR.Set(uint32(mask & (SPI_IRQ_RX_OVERRUN | SPI_IRQ_RX_TIMEOUT)))
+}
+
+// Pop a frame from the RX FIFO, which should be readable
+//
+//go:inline
+func SPI_get_data(spi uint32) uint16 {
+	assert(spi < NUM_SPIS)
+	return uint16(spi_groups[spi].SSPDR.Get())
+}
+
+// Push a frame to the TX FIFO, which should be writable
+//
+//go:inline
+func SPI_put_data(spi uint32, v uint16) {
+	assert(spi < NUM_SPIS)
+	spi_groups[spi].SSPDR.Set(uint32(v))
 }
 
 // Check whether a write can be done on SPI device
@@ -161,9 +235,10 @@ func SPI_set_slave(spi uint32, slave bool) {
 // Although the controllers each have a 8 deep TX FIFO, the current HW
 // implementation can only return 0 or 1 rather than the space available.
 //
-func SPI_is_writable(spi uint32) uint32 {
+//go:inline
+func SPI_is_writable(spi uint32) bool {
 	assert(spi < NUM_SPIS)
-	return (spi_groups[spi].SSPSR.Get() & rp.SPI0_SSPSR_TNF_Msk) >> rp.SPI0_SSPSR_TNF_Pos
+	return spi_groups[spi].SSPSR.HasBits(_SPI_SSPSR_TNF)
 }
 
 // Check whether a read can be done on SPI device
@@ -171,68 +246,94 @@ func SPI_is_writable(spi uint32) uint32 {
 // Although the controllers each have a 8 deep RX FIFO,
 // the current HW implementation can only return 0 or 1
 //
-func SPI_is_readable(spi uint32) uint32 {
+//go:inline
+func SPI_is_readable(spi uint32) bool {
+	assert(spi < NUM_SPIS)
+	return spi_groups[spi].SSPSR.HasBits(_SPI_SSPSR_RNE)
+}
+
+// Return the address of the data register, which is the source or
+// destination of DMA transfers
+//
+//go:inline
+func SPI_get_data_addr(spi uint32) unsafe.Pointer {
 	assert(spi < NUM_SPIS)
-	return (spi_groups[spi].SSPSR.Get() & rp.SPI0_SSPSR_RNE_Msk) >> rp.SPI0_SSPSR_RNE_Pos
+	return unsafe.Pointer(&spi_groups[spi].SSPDR)
+}
+
+// Check whether SPI is busy
+//
+//go:inline
+func SPI_is_busy(spi uint32) bool {
+	assert(spi < NUM_SPIS)
+	return spi_groups[spi].SSPSR.HasBits(_SPI_SSPSR_BSY)
 }
 
 // Write/Read to/from an SPI device
 //
-func SPI_write_read_blocking(spi uint32, w, r []uint8) {
-	assert(spi < NUM_SPIS)
-	assert(len(w) > 0)
-
-	// Never have more transfers in flight than will fit into the RX FIFO,
-	// else FIFO will overflow if this code is heavily interrupted
-	rx_remaining := uint32(len(r))
-	tx_remaining := uint32(len(w))
-	for rx_remaining > 0 && tx_remaining > 0 {
-		if tx_remaining > 0 && bits_to_bool(SPI_is_writable(spi)) && rx_remaining-tx_remaining < _SPI_FIFO_DEPTH {
-			spi_groups[spi].SSPDR.Set(w[0])
-			tx_remaining--
-		}
-		if rx_remaining > 0 && bits_to_bool(SPI_is_readable(spi)) {
-			r[0] = spi_groups[spi].SSPDR.Get()
-			rx_remaining--
-		}
-	}
+// Blocks until all data is transferred. The buffers must be the same length.
+// Returns the number of bytes transferred.
+//
+//go:inline
+func SPI_write_read_blocking(spi uint32, w, r []uint8) uint32 {
+	assert(spi < NUM_SPIS)
+	assert(len(w) == len(r))
+	return spi_write_read_blocking(spi_groups[spi], w, r)
 }
 
 // Write to an SPI device, blocking
 //
-func SPI_write_blocking(spi_inst_t *spi, w []uint8) uint32
+// Data received on RX is discarded. Returns the number of bytes written.
+//
+//go:inline
+func SPI_write_blocking(spi uint32, w []uint8) uint32 {
+	assert(spi < NUM_SPIS)
+	return spi_write_blocking(spi_groups[spi], w)
+}
 
 // Read from an SPI device
 //
 // Blocks until all data is transferred. No timeout, as SPI hardware always transfers at a known data rate.
-// repeated_tx_data is output repeatedly on TX as data is read in from RX
-func SPI_read_blocking(spi uint32, repeated_tx_data uint8, r []uint8)
+// repeated_tx_data is output repeatedly on TX as data is read in from RX. Returns the number of bytes read.
+//
+//go:inline
+func SPI_read_blocking(spi uint32, repeated_tx_data uint8, r []uint8) uint32 {
+	assert(spi < NUM_SPIS)
+	return spi_read_blocking(spi_groups[spi], repeated_tx_data, r)
+}
 
 // Write/Read half words to/from an SPI device
 //
-func spi_write16_read16_blocking(spi uint32, w, r []uint16)
+// Blocks until all data is transferred. The buffers must be the same length, and
+// the SPI should be configured for frames of more than eight bits. Returns the
+// number of half words transferred.
+//
+//go:inline
+func SPI_write16_read16_blocking(spi uint32, w, r []uint16) uint32 {
+	assert(spi < NUM_SPIS)
+	assert(len(w) == len(r))
+	return spi_write16_read16_blocking(spi_groups[spi], w, r)
+}
 
 // Write half words to an SPI device
 //
-func SPI_write16_blocking(spi uint32, w []uint16) uint32
+// Data received on RX is discarded. The SPI should be configured for frames of
+// more than eight bits. Returns the number of half words written.
+//
+//go:inline
+func SPI_write16_blocking(spi uint32, w []uint16) uint32 {
+	assert(spi < NUM_SPIS)
+	return spi_write16_blocking(spi_groups[spi], w)
+}
 
 // Read half words from an SPI device
 //
 // Blocks until all data is transferred. No timeout, as SPI hardware always transfers at
-// a known data rate. repeated_tx_data is output repeatedly on TX as data is read in from RX
+// a known data rate. repeated_tx_data is output repeatedly on TX as data is read in from RX.
+// Returns the number of half words read.
 //
-func SPI_read16_blocking(spi uint32, repeated_tx_data uint16, w []uint16) uint32
-
-
-
-	SSPCR0       volatile.Register32 // 0x0
-	SSPCR1       volatile.Register32 // 0x4
-	SSPDR        volatile.Register32 // 0x8
-	SSPSR        volatile.Register32 // 0xC
-	SSPCPSR      volatile.Register32 // 0x10
-	SSPIMSC      volatile.Register32 // 0x14
-	SSPRIS       volatile.Register32 // 0x18
-	SSPMIS       volatile.Register32 // 0x1C
-	SSPICR       volatile.Register32 // 0x20
-	SSPDMACR     volatile.Register32 // 0x24
-*/
+//go:inline
+func SPI_read16_blocking(spi uint32, repeated_tx_data uint16, r []uint16) uint32 {
+	assert(spi < NUM_SPIS)
+	return spi_read16_blocking(spi_groups[spi], repeated_tx_data, r)
+}
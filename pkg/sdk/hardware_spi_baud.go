@@ -0,0 +1,59 @@
+package sdk
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	SPI_MIN_PRESCALE = 2   // Minimum prescale, which is even
+	SPI_MAX_PRESCALE = 254 // Maximum prescale, which is even
+	SPI_MAX_POSTDIV  = 256 // Maximum post-divide
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the prescale and post-divide values for a baud rate given the
+// peripheral clock frequency freq_in, and the achieved rate, which is the
+// highest rate which does not exceed the baud rate. Rates above freq_in/2 are
+// clamped to freq_in/2, and rates which are too low are clamped to the
+// minimum rate. A baud rate of zero returns zero values.
+//
+// The Pico SDK 1.4.0 tests prescale against (prescale+2)*256, which can
+// select a prescale which is too small for the post-divide to reach the baud
+// rate, and so return a rate which is higher than requested. Here the
+// smallest prescale for which the post-divide can reach the rate is chosen.
+func SPI_baudrate_solve(freq_in, baudrate uint32) (uint32, uint32, uint32) {
+	if baudrate == 0 || freq_in == 0 {
+		return 0, 0, 0
+	}
+
+	// Find smallest prescale value which puts output frequency in range of
+	// post-divide. Prescale is an even number from 2 to 254 inclusive.
+	prescale := uint64(SPI_MIN_PRESCALE)
+	for ; prescale < SPI_MAX_PRESCALE; prescale += 2 {
+		if uint64(freq_in) < prescale*SPI_MAX_POSTDIV*uint64(baudrate) {
+			break
+		}
+	}
+
+	// Find largest post-divide which makes output <= baudrate. Post-divide is
+	// an integer in the range 1 to 256 inclusive.
+	postdiv := uint64(SPI_MAX_POSTDIV)
+	for ; postdiv > 1; postdiv-- {
+		if uint64(freq_in)/(prescale*(postdiv-1)) > uint64(baudrate) {
+			break
+		}
+	}
+
+	// Return the values and the frequency we were able to achieve
+	return uint32(prescale), uint32(postdiv), uint32(uint64(freq_in) / (prescale * postdiv))
+}
+
+// Return the baud rate for prescale and post-divide values given the
+// peripheral clock frequency freq_in
+func SPI_baudrate_for(freq_in, prescale, postdiv uint32) uint32 {
+	if prescale == 0 || postdiv == 0 {
+		return 0
+	}
+	return uint32(uint64(freq_in) / (uint64(prescale) * uint64(postdiv)))
+}
@@ -0,0 +1,88 @@
+package sdk_test
+
+import (
+	"testing"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+func Test_SPI_Baud_001(t *testing.T) {
+	// Values which match the Pico SDK
+	tests := []struct {
+		freq, baud                  uint32
+		prescale, postdiv, achieved uint32
+	}{
+		{125_000_000, 62_500_000, 2, 1, 62_500_000},
+		{125_000_000, 50_000_000, 2, 2, 31_250_000},
+		{125_000_000, 20_000_000, 2, 4, 15_625_000},
+		{125_000_000, 10_000_000, 2, 7, 8_928_571},
+		{125_000_000, 1_000_000, 2, 63, 992_063},
+		{125_000_000, 500_000, 2, 125, 500_000},
+		{48_000_000, 10_000_000, 2, 3, 8_000_000},
+		{48_000_000, 1_000_000, 2, 24, 1_000_000},
+		{48_000_000, 100_000, 2, 240, 100_000},
+		{133_000_000, 1_000_000, 2, 67, 992_537},
+	}
+	for _, test := range tests {
+		prescale, postdiv, achieved := SPI_baudrate_solve(test.freq, test.baud)
+		if prescale != test.prescale || postdiv != test.postdiv || achieved != test.achieved {
+			t.Errorf("SPI_baudrate_solve(%d, %d) = %d, %d, %d, expected %d, %d, %d", test.freq, test.baud, prescale, postdiv, achieved, test.prescale, test.postdiv, test.achieved)
+		}
+		if rate := SPI_baudrate_for(test.freq, prescale, postdiv); rate != achieved {
+			t.Errorf("SPI_baudrate_for(%d, %d, %d) = %d, expected %d", test.freq, prescale, postdiv, rate, achieved)
+		}
+	}
+}
+
+func Test_SPI_Baud_002(t *testing.T) {
+	// Low rates, where the Pico SDK 1.4.0 returns a rate higher than requested
+	// (122070 and 10172 at 125MHz)
+	tests := []struct {
+		freq, baud                  uint32
+		prescale, postdiv, achieved uint32
+	}{
+		{125_000_000, 100_000, 6, 209, 99_681},
+		{125_000_000, 10_000, 50, 250, 10_000},
+		{133_000_000, 100_000, 6, 222, 99_849},
+		{48_000_000, 1_000, 188, 256, 997},
+	}
+	for _, test := range tests {
+		prescale, postdiv, achieved := SPI_baudrate_solve(test.freq, test.baud)
+		if prescale != test.prescale || postdiv != test.postdiv || achieved != test.achieved {
+			t.Errorf("SPI_baudrate_solve(%d, %d) = %d, %d, %d, expected %d, %d, %d", test.freq, test.baud, prescale, postdiv, achieved, test.prescale, test.postdiv, test.achieved)
+		}
+	}
+}
+
+func Test_SPI_Baud_003(t *testing.T) {
+	// Clamped rates
+	if _, _, achieved := SPI_baudrate_solve(125_000_000, 100_000_000); achieved != 62_500_000 {
+		t.Error("Expected maximum rate, got", achieved)
+	}
+	if prescale, postdiv, achieved := SPI_baudrate_solve(125_000_000, 1_000); prescale != SPI_MAX_PRESCALE || postdiv != SPI_MAX_POSTDIV || achieved != 1_922 {
+		t.Error("Expected minimum rate, got", prescale, postdiv, achieved)
+	}
+	if prescale, postdiv, achieved := SPI_baudrate_solve(125_000_000, 0); prescale != 0 || postdiv != 0 || achieved != 0 {
+		t.Error("Expected zero values, got", prescale, postdiv, achieved)
+	}
+}
+
+func Test_SPI_Baud_004(t *testing.T) {
+	// Achieved rate never exceeds the requested rate, and values are in range
+	for _, freq := range []uint32{48_000_000, 125_000_000, 133_000_000} {
+		min := freq / (SPI_MAX_PRESCALE * SPI_MAX_POSTDIV)
+		for baud := min + 1; baud <= freq/2; baud += baud/7 + 1 {
+			prescale, postdiv, achieved := SPI_baudrate_solve(freq, baud)
+			if achieved > baud {
+				t.Fatalf("freq=%d baud=%d: achieved %d", freq, baud, achieved)
+			}
+			if prescale < SPI_MIN_PRESCALE || prescale > SPI_MAX_PRESCALE || prescale&1 != 0 {
+				t.Fatalf("freq=%d baud=%d: prescale %d", freq, baud, prescale)
+			}
+			if postdiv < 1 || postdiv > SPI_MAX_POSTDIV {
+				t.Fatalf("freq=%d baud=%d: postdiv %d", freq, baud, postdiv)
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package sdk
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// spi_hw_t is the register block of a PL022 synchronous serial port
+type spi_hw_t struct {
+	SSPCR0   register32 // 0x0
+	SSPCR1   register32 // 0x4
+	SSPDR    register32 // 0x8
+	SSPSR    register32 // 0xC
+	SSPCPSR  register32 // 0x10
+	SSPIMSC  register32 // 0x14
+	SSPRIS   register32 // 0x18
+	SSPMIS   register32 // 0x1C
+	SSPICR   register32 // 0x20
+	SSPDMACR register32 // 0x24
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	_SPI_FIFO_DEPTH = 8
+)
+
+// Register bits, which are the same as in device/rp but are repeated here
+// so that they can be tested on the host
+const (
+	_SPI_SSPSR_TFE    = 1 << 0 // Transmit FIFO empty
+	_SPI_SSPSR_TNF    = 1 << 1 // Transmit FIFO not full
+	_SPI_SSPSR_RNE    = 1 << 2 // Receive FIFO not empty
+	_SPI_SSPSR_RFF    = 1 << 3 // Receive FIFO full
+	_SPI_SSPSR_BSY    = 1 << 4 // Busy
+	_SPI_SSPICR_RORIC = 1 << 0 // Clear receive overrun
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the data request signal for the TX or RX FIFO of an interface,
+// which paces DMA transfers to or from the data register
+func SPI_get_dreq(spi uint32, is_tx bool) DMA_dreq_t {
+	assert(spi < NUM_SPIS)
+	if is_tx {
+		return DREQ_SPI0_TX + DMA_dreq_t(spi*2)
+	}
+	return DREQ_SPI0_RX + DMA_dreq_t(spi*2)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+//go:inline
+func spi_is_writable(hw *spi_hw_t) bool {
+	return hw.SSPSR.HasBits(_SPI_SSPSR_TNF)
+}
+
+//go:inline
+func spi_is_readable(hw *spi_hw_t) bool {
+	return hw.SSPSR.HasBits(_SPI_SSPSR_RNE)
+}
+
+// Write and read bytes in full duplex, and return the number of bytes
+// transferred
+func spi_write_read_blocking(hw *spi_hw_t, w, r []uint8) uint32 {
+	// Never have more transfers in flight than will fit into the RX FIFO,
+	// else FIFO will overflow if this code is heavily interrupted
+	n := min_int(len(w), len(r))
+	rx, tx := 0, 0
+	for rx < n || tx < n {
+		if tx < n && spi_is_writable(hw) && tx-rx < _SPI_FIFO_DEPTH {
+			hw.SSPDR.Set(uint32(w[tx]))
+			tx++
+		}
+		if rx < n && spi_is_readable(hw) {
+			r[rx] = uint8(hw.SSPDR.Get())
+			rx++
+		}
+	}
+	return uint32(n)
+}
+
+// Write bytes, discarding data received, and return the number of bytes
+// written
+func spi_write_blocking(hw *spi_hw_t, w []uint8) uint32 {
+	// Write to TX FIFO whilst ignoring RX, then clean up afterward. When RX
+	// is full, PL022 inhibits RX pushes, and sets a sticky flag on
+	// push-on-full, but continues shifting. Safe if SSPIMSC_RORIM is not set.
+	for _, v := range w {
+		for !spi_is_writable(hw) {
+		}
+		hw.SSPDR.Set(uint32(v))
+	}
+	spi_drain(hw)
+	return uint32(len(w))
+}
+
+// Read bytes while writing a repeated byte, and return the number of bytes
+// read
+func spi_read_blocking(hw *spi_hw_t, repeated_tx_data uint8, r []uint8) uint32 {
+	rx, tx := 0, 0
+	for rx < len(r) || tx < len(r) {
+		if tx < len(r) && spi_is_writable(hw) && tx-rx < _SPI_FIFO_DEPTH {
+			hw.SSPDR.Set(uint32(repeated_tx_data))
+			tx++
+		}
+		if rx < len(r) && spi_is_readable(hw) {
+			r[rx] = uint8(hw.SSPDR.Get())
+			rx++
+		}
+	}
+	return uint32(len(r))
+}
+
+// Write and read half words in full duplex, and return the number of half
+// words transferred
+func spi_write16_read16_blocking(hw *spi_hw_t, w, r []uint16) uint32 {
+	n := min_int(len(w), len(r))
+	rx, tx := 0, 0
+	for rx < n || tx < n {
+		if tx < n && spi_is_writable(hw) && tx-rx < _SPI_FIFO_DEPTH {
+			hw.SSPDR.Set(uint32(w[tx]))
+			tx++
+		}
+		if rx < n && spi_is_readable(hw) {
+			r[rx] = uint16(hw.SSPDR.Get())
+			rx++
+		}
+	}
+	return uint32(n)
+}
+
+// Write half words, discarding data received, and return the number of half
+// words written
+func spi_write16_blocking(hw *spi_hw_t, w []uint16) uint32 {
+	// Deliberately overflow FIFO, then clean up afterward, to minimise amount
+	// of APB polling required per halfword
+	for _, v := range w {
+		for !spi_is_writable(hw) {
+		}
+		hw.SSPDR.Set(uint32(v))
+	}
+	spi_drain(hw)
+	return uint32(len(w))
+}
+
+// Read half words while writing a repeated half word, and return the number
+// of half words read
+func spi_read16_blocking(hw *spi_hw_t, repeated_tx_data uint16, r []uint16) uint32 {
+	rx, tx := 0, 0
+	for rx < len(r) || tx < len(r) {
+		if tx < len(r) && spi_is_writable(hw) && tx-rx < _SPI_FIFO_DEPTH {
+			hw.SSPDR.Set(uint32(repeated_tx_data))
+			tx++
+		}
+		if rx < len(r) && spi_is_readable(hw) {
+			r[rx] = uint16(hw.SSPDR.Get())
+			rx++
+		}
+	}
+	return uint32(len(r))
+}
+
+// Drain the RX FIFO after a write-only transfer
+//
+// Drain RX FIFO, then wait for shifting to finish (which may be *after* TX
+// FIFO drains), then drain RX FIFO again. Finally clear the overrun flag, which
+// is set when the RX FIFO was full.
+func spi_drain(hw *spi_hw_t) {
+	for spi_is_readable(hw) {
+		hw.SSPDR.Get()
+	}
+	for hw.SSPSR.HasBits(_SPI_SSPSR_BSY) {
+	}
+	for spi_is_readable(hw) {
+		hw.SSPDR.Get()
+	}
+	hw.SSPICR.Set(_SPI_SSPICR_RORIC)
+}
@@ -0,0 +1,227 @@
+package sdk
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// fake_ssp emulates a PL022 in loopback, where each frame written to TX is
+// shifted out and echoed into RX. One frame is shifted each time the status
+// register is read. When RX is full, frames are dropped and the overrun flag
+// is set.
+type fake_ssp struct {
+	hw      spi_hw_t
+	tx, rx  []uint32
+	overrun bool
+	sent    []uint32
+}
+
+func new_fake_ssp(t *testing.T) *fake_ssp {
+	f := new(fake_ssp)
+	register_fakes[&f.hw.SSPDR] = register_fake{
+		get: func() uint32 {
+			if len(f.rx) == 0 {
+				t.Fatal("Read from empty RX FIFO")
+			}
+			v := f.rx[0]
+			f.rx = f.rx[1:]
+			return v
+		},
+		set: func(v uint32) {
+			if len(f.tx) >= _SPI_FIFO_DEPTH {
+				t.Fatal("Write to full TX FIFO")
+			}
+			f.tx = append(f.tx, v)
+		},
+	}
+	register_fakes[&f.hw.SSPSR] = register_fake{
+		get: func() uint32 {
+			f.shift()
+			v := uint32(0)
+			if len(f.tx) == 0 {
+				v |= _SPI_SSPSR_TFE
+			} else {
+				v |= _SPI_SSPSR_BSY
+			}
+			if len(f.tx) < _SPI_FIFO_DEPTH {
+				v |= _SPI_SSPSR_TNF
+			}
+			if len(f.rx) > 0 {
+				v |= _SPI_SSPSR_RNE
+			}
+			if len(f.rx) >= _SPI_FIFO_DEPTH {
+				v |= _SPI_SSPSR_RFF
+			}
+			return v
+		},
+	}
+	register_fakes[&f.hw.SSPICR] = register_fake{
+		set: func(v uint32) {
+			if v&_SPI_SSPICR_RORIC != 0 {
+				f.overrun = false
+			}
+		},
+	}
+	t.Cleanup(func() {
+		delete(register_fakes, &f.hw.SSPDR)
+		delete(register_fakes, &f.hw.SSPSR)
+		delete(register_fakes, &f.hw.SSPICR)
+	})
+	return f
+}
+
+// Shift one frame from TX to RX
+func (f *fake_ssp) shift() {
+	if len(f.tx) == 0 {
+		return
+	}
+	v := f.tx[0]
+	f.tx = f.tx[1:]
+	f.sent = append(f.sent, v)
+	if len(f.rx) >= _SPI_FIFO_DEPTH {
+		f.overrun = true
+	} else {
+		f.rx = append(f.rx, v)
+	}
+}
+
+func Test_SPI_Regs_001(t *testing.T) {
+	// Register layout matches the datasheet
+	hw := spi_hw_t{}
+	if size := unsafe.Sizeof(hw); size != 0x28 {
+		t.Errorf("Unexpected size 0x%X", size)
+	}
+	if offset := unsafe.Offsetof(hw.SSPDR); offset != 0x8 {
+		t.Errorf("Unexpected SSPDR offset 0x%X", offset)
+	}
+	if offset := unsafe.Offsetof(hw.SSPDMACR); offset != 0x24 {
+		t.Errorf("Unexpected SSPDMACR offset 0x%X", offset)
+	}
+}
+
+func Test_SPI_Regs_002(t *testing.T) {
+	// Full duplex transfer echoes every byte, without overflowing RX
+	for _, n := range []int{0, 1, 7, 8, 9, 100} {
+		f := new_fake_ssp(t)
+		w, r := make([]uint8, n), make([]uint8, n)
+		for i := range w {
+			w[i] = uint8(i*7 + 1)
+		}
+		if got := spi_write_read_blocking(&f.hw, w, r); got != uint32(n) {
+			t.Errorf("n=%d: Expected %d transferred, got %d", n, n, got)
+		}
+		for i := range w {
+			if r[i] != w[i] {
+				t.Errorf("n=%d: r[%d] = %d, expected %d", n, i, r[i], w[i])
+				break
+			}
+		}
+		if f.overrun {
+			t.Errorf("n=%d: RX FIFO overflowed", n)
+		}
+		if len(f.tx) != 0 || len(f.rx) != 0 {
+			t.Errorf("n=%d: FIFOs not empty", n)
+		}
+	}
+}
+
+func Test_SPI_Regs_003(t *testing.T) {
+	// Full duplex transfer of half words
+	f := new_fake_ssp(t)
+	w, r := make([]uint16, 50), make([]uint16, 50)
+	for i := range w {
+		w[i] = uint16(i*1000 + 3)
+	}
+	if got := spi_write16_read16_blocking(&f.hw, w, r); got != 50 {
+		t.Error("Unexpected count", got)
+	}
+	for i := range w {
+		if r[i] != w[i] {
+			t.Errorf("r[%d] = %d, expected %d", i, r[i], w[i])
+		}
+	}
+	if f.overrun {
+		t.Error("RX FIFO overflowed")
+	}
+}
+
+func Test_SPI_Regs_004(t *testing.T) {
+	// Write-only transfer sends every byte, leaves RX empty and clears overrun
+	f := new_fake_ssp(t)
+	w := make([]uint8, 40)
+	for i := range w {
+		w[i] = uint8(i)
+	}
+	if got := spi_write_blocking(&f.hw, w); got != 40 {
+		t.Error("Unexpected count", got)
+	}
+	if len(f.sent) != len(w) {
+		t.Fatalf("Expected %d bytes sent, got %d", len(w), len(f.sent))
+	}
+	for i := range w {
+		if f.sent[i] != uint32(w[i]) {
+			t.Errorf("sent[%d] = %d, expected %d", i, f.sent[i], w[i])
+		}
+	}
+	if len(f.tx) != 0 || len(f.rx) != 0 {
+		t.Error("FIFOs not empty")
+	}
+	if f.overrun {
+		t.Error("Overrun flag not cleared")
+	}
+
+	// Same for half words
+	f = new_fake_ssp(t)
+	if got := spi_write16_blocking(&f.hw, []uint16{1, 2, 3, 0xFFFF}); got != 4 {
+		t.Error("Unexpected count", got)
+	}
+	if len(f.sent) != 4 || f.sent[3] != 0xFFFF || len(f.rx) != 0 {
+		t.Error("Unexpected state after write16", f.sent, f.rx)
+	}
+}
+
+func Test_SPI_Regs_005(t *testing.T) {
+	// Read-only transfer repeats the TX data
+	f := new_fake_ssp(t)
+	r := make([]uint8, 20)
+	if got := spi_read_blocking(&f.hw, 0xA5, r); got != 20 {
+		t.Error("Unexpected count", got)
+	}
+	for i := range r {
+		if r[i] != 0xA5 {
+			t.Errorf("r[%d] = 0x%X", i, r[i])
+		}
+	}
+	if len(f.sent) != len(r) || f.overrun {
+		t.Error("Unexpected state after read", len(f.sent), f.overrun)
+	}
+
+	// Same for half words
+	f = new_fake_ssp(t)
+	r16 := make([]uint16, 20)
+	spi_read16_blocking(&f.hw, 0x1234, r16)
+	for i := range r16 {
+		if r16[i] != 0x1234 {
+			t.Errorf("r16[%d] = 0x%X", i, r16[i])
+		}
+	}
+}
+
+func Test_SPI_Regs_006(t *testing.T) {
+	// Data request signals for each interface
+	tests := []struct {
+		spi   uint32
+		is_tx bool
+		want  DMA_dreq_t
+	}{
+		{0, true, DREQ_SPI0_TX},
+		{0, false, DREQ_SPI0_RX},
+		{1, true, DREQ_SPI1_TX},
+		{1, false, DREQ_SPI1_RX},
+	}
+	for _, test := range tests {
+		if dreq := SPI_get_dreq(test.spi, test.is_tx); dreq != test.want {
+			t.Errorf("SPI_get_dreq(%d, %v) = %d, expected %d", test.spi, test.is_tx, dreq, test.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+//go:build rp2040
+
+package sdk
+
+import (
+	"unsafe"
+
+	// Module imports
+	rp "device/rp"
+	interrupt "runtime/interrupt"
+)
+
+// SDK documentation
+// https://github.com/raspberrypi/pico-sdk/tree/master/src/rp2_common/hardware_sync
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	PICO_SPINLOCK_ID_HARDWARE_CLAIM   = 11 // Protects claiming of hardware
+	PICO_SPINLOCK_ID_CLAIM_FREE_FIRST = 24 // First spin lock which can be claimed
+	PICO_SPINLOCK_ID_CLAIM_FREE_LAST  = 31 // Last spin lock which can be claimed
+)
+
+const (
+	_SIO_SPINLOCK0_OFFSET = 0x100
+)
+
+var (
+	spin_locks         = (*[NUM_SPIN_LOCKS]register32)(unsafe.Pointer(uintptr(unsafe.Pointer(rp.SIO)) + _SIO_SPINLOCK0_OFFSET))
+	spin_locks_claimed uint32
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Acquire a spin lock without disabling interrupts
+//
+// Reading the spin lock register returns non-zero when the lock was acquired
+//
+//go:inline
+func Spin_lock_unsafe_blocking(num uint32) {
+	assert(num < NUM_SPIN_LOCKS)
+	for spin_locks[num].Get() == 0 {
+	}
+}
+
+// Release a spin lock without restoring interrupts
+//
+//go:inline
+func Spin_unlock_unsafe(num uint32) {
+	assert(num < NUM_SPIN_LOCKS)
+	spin_locks[num].Set(0)
+}
+
+// Try to acquire a spin lock without blocking, and return true if acquired
+//
+//go:inline
+func Spin_try_lock_unsafe(num uint32) bool {
+	assert(num < NUM_SPIN_LOCKS)
+	return spin_locks[num].Get() != 0
+}
+
+// Acquire a spin lock, disabling interrupts on this core
+//
+// Returns the interrupt state, which is restored by Spin_unlock
+//
+//go:inline
+func Spin_lock_blocking(num uint32) interrupt.State {
+	state := interrupt.Disable()
+	Spin_lock_unsafe_blocking(num)
+	return state
+}
+
+// Release a spin lock, and restore interrupts
+//
+//go:inline
+func Spin_unlock(num uint32, state interrupt.State) {
+	Spin_unlock_unsafe(num)
+	interrupt.Restore(state)
+}
+
+// Claim a free spin lock
+//
+// Returns the spin lock number, or false if all spin locks have been claimed
+func Spin_lock_claim_unused() (uint32, bool) {
+	state := Spin_lock_blocking(PICO_SPINLOCK_ID_HARDWARE_CLAIM)
+	defer Spin_unlock(PICO_SPINLOCK_ID_HARDWARE_CLAIM, state)
+	for num := uint32(PICO_SPINLOCK_ID_CLAIM_FREE_FIRST); num <= PICO_SPINLOCK_ID_CLAIM_FREE_LAST; num++ {
+		if spin_locks_claimed&(1<<num) == 0 {
+			spin_locks_claimed |= 1 << num
+			return num, true
+		}
+	}
+	return 0, false
+}
+
+// Release a claimed spin lock
+func Spin_lock_unclaim(num uint32) {
+	assert(num >= PICO_SPINLOCK_ID_CLAIM_FREE_FIRST && num <= PICO_SPINLOCK_ID_CLAIM_FREE_LAST)
+	state := Spin_lock_blocking(PICO_SPINLOCK_ID_HARDWARE_CLAIM)
+	defer Spin_unlock(PICO_SPINLOCK_ID_HARDWARE_CLAIM, state)
+	Spin_unlock_unsafe(num)
+	spin_locks_claimed &^= 1 << num
+}
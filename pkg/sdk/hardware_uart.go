@@ -0,0 +1,320 @@
+//go:build rp2040
+
+package sdk
+
+import (
+	"unsafe"
+
+	// Module imports
+	rp "device/rp"
+)
+
+// SDK documentation
+// https://github.com/raspberrypi/pico-sdk/tree/master/src/rp2_common/hardware_uart
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type (
+	UART_irq_t uint32
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	PICO_DEFAULT_UART_BAUD_RATE = 115200
+)
+
+const (
+	UART_IRQ_RX      UART_irq_t = rp.UART0_UARTIMSC_RXIM // RX FIFO at or above the level
+	UART_IRQ_TX      UART_irq_t = rp.UART0_UARTIMSC_TXIM // TX FIFO at or below the level
+	UART_IRQ_RX_TIME UART_irq_t = rp.UART0_UARTIMSC_RTIM // RX FIFO not empty and no data received for 32 bit periods
+	UART_IRQ_FRAME   UART_irq_t = rp.UART0_UARTIMSC_FEIM // Framing error
+	UART_IRQ_PARITY  UART_irq_t = rp.UART0_UARTIMSC_PEIM // Parity error
+	UART_IRQ_BREAK   UART_irq_t = rp.UART0_UARTIMSC_BEIM // Break received
+	UART_IRQ_OVERRUN UART_irq_t = rp.UART0_UARTIMSC_OEIM // Data received when the RX FIFO was full
+)
+
+const (
+	UART_DATA_FRAME   = 1 << 8  // Byte received with a framing error
+	UART_DATA_PARITY  = 1 << 9  // Byte received with a parity error
+	UART_DATA_BREAK   = 1 << 10 // Break received
+	UART_DATA_OVERRUN = 1 << 11 // RX FIFO overflowed before this byte
+)
+
+const (
+	UART_FIFO_LEVEL_1_8 = 0 // FIFO 1/8 full (4 bytes)
+	UART_FIFO_LEVEL_1_4 = 1 // FIFO 1/4 full (8 bytes)
+	UART_FIFO_LEVEL_1_2 = 2 // FIFO 1/2 full (16 bytes)
+	UART_FIFO_LEVEL_3_4 = 3 // FIFO 3/4 full (24 bytes)
+	UART_FIFO_LEVEL_7_8 = 4 // FIFO 7/8 full (28 bytes)
+)
+
+var (
+	uart_groups = [NUM_UARTS]*uart_hw_t{
+		(*uart_hw_t)(unsafe.Pointer(rp.UART0)),
+		(*uart_hw_t)(unsafe.Pointer(rp.UART1)),
+	}
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Initialise a UART with 8 data bits, 1 stop bit and no parity, with the
+// FIFOs enabled, and return the actual baud rate. Returns zero if clk_peri
+// is not running.
+//
+func UART_init(uart, baudrate uint32) uint32 {
+	assert(uart < NUM_UARTS)
+	if CLOCK_get_hz(CLOCK_peri) == 0 {
+		return 0
+	}
+	UART_reset(uart)
+	UART_unreset(uart)
+	baudrate = UART_set_baudrate(uart, baudrate)
+	UART_set_format(uart, 8, 1, UART_PARITY_NONE)
+
+	// Enable FIFOs (must be before setting UARTEN, as this is an LCR access)
+	uart_groups[uart].UARTLCR_H.SetBits(_UART_UARTLCR_H_FEN)
+
+	// Enable the UART, both TX and RX
+	uart_groups[uart].UARTCR.Set(_UART_UARTCR_UARTEN | _UART_UARTCR_TXE | _UART_UARTCR_RXE)
+
+	// Always enable DREQ signals -- no harm in this if DMA is not listening
+	uart_groups[uart].UARTDMACR.Set(_UART_UARTDMACR_TXDMAE | _UART_UARTDMACR_RXDMAE)
+
+	// Return the actual baudrate
+	return baudrate
+}
+
+// Disable a UART
+//
+func UART_deinit(uart uint32) {
+	assert(uart < NUM_UARTS)
+	UART_reset(uart)
+}
+
+// Reset UART
+//
+func UART_reset(uart uint32) {
+	assert(uart < NUM_UARTS)
+	switch uart {
+	case 0:
+		reset_block(rp.RESETS_RESET_UART0_Msk)
+	case 1:
+		reset_block(rp.RESETS_RESET_UART1_Msk)
+	}
+}
+
+// Unreset UART
+//
+func UART_unreset(uart uint32) {
+	assert(uart < NUM_UARTS)
+	switch uart {
+	case 0:
+		unreset_block_wait(rp.RESETS_RESET_UART0_Msk)
+	case 1:
+		unreset_block_wait(rp.RESETS_RESET_UART1_Msk)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Determine the UART instance for TX and RX pins, which must be on the same
+// instance. TX pins are 0 modulo 4 and RX pins are 1 modulo 4, and the
+// instance alternates every eight pins, starting at GPIO4.
+//
+func UART_gpio_to_inst(tx, rx GPIO_pin) (uint32, bool) {
+	if tx >= NUM_BANK0_GPIOS || rx >= NUM_BANK0_GPIOS {
+		return 0, false
+	}
+	if tx&3 != 0 || rx&3 != 1 {
+		return 0, false
+	}
+	inst := uint32((tx+4)>>3) & 1
+	if uint32((rx+4)>>3)&1 != inst {
+		return 0, false
+	}
+	return inst, true
+}
+
+// Set UART baud rate as close as possible to baudrate, and return the actual
+// rate. The rate is derived from clk_peri.
+//
+func UART_set_baudrate(uart, baudrate uint32) uint32 {
+	assert(uart < NUM_UARTS)
+	assert(baudrate > 0)
+	ibrd, fbrd, actual := UART_baudrate_solve(CLOCK_get_hz(CLOCK_peri), baudrate)
+	uart_set_divisors(uart_groups[uart], ibrd, fbrd)
+	return actual
+}
+
+// Return the current baud rate, from the divisors
+//
+func UART_get_baudrate(uart uint32) uint32 {
+	assert(uart < NUM_UARTS)
+	return UART_baudrate_for(CLOCK_get_hz(CLOCK_peri), uart_groups[uart].UARTIBRD.Get(), uart_groups[uart].UARTFBRD.Get())
+}
+
+// Set UART data format, with 5 to 8 data bits and 1 or 2 stop bits
+//
+func UART_set_format(uart, data_bits, stop_bits uint32, parity UART_parity_t) {
+	assert(uart < NUM_UARTS)
+	assert(data_bits >= UART_MIN_DATA_BITS && data_bits <= UART_MAX_DATA_BITS)
+	assert(stop_bits >= UART_MIN_STOP_BITS && stop_bits <= UART_MAX_STOP_BITS)
+	assert(parity <= UART_PARITY_ODD)
+	uart_groups[uart].UARTLCR_H.ReplaceBits(uart_format_bits(data_bits, stop_bits, parity), _UART_UARTLCR_H_FORMAT_MSK, 0)
+}
+
+// Set UART flow control. If cts is true, data is only sent while the CTS input
+// is asserted. If rts is true, the RTS output is asserted while there is space
+// in the RX FIFO.
+//
+func UART_set_hw_flow(uart uint32, cts, rts bool) {
+	assert(uart < NUM_UARTS)
+	uart_set_hw_flow(uart_groups[uart], cts, rts)
+}
+
+// Test if a UART is enabled
+//
+//go:inline
+func UART_is_enabled(uart uint32) bool {
+	assert(uart < NUM_UARTS)
+	return uart_groups[uart].UARTCR.HasBits(_UART_UARTCR_UARTEN)
+}
+
+// Enable or disable the FIFOs. When disabled, each FIFO holds one byte.
+//
+func UART_set_fifo_enabled(uart uint32, enabled bool) {
+	assert(uart < NUM_UARTS)
+	if enabled {
+		uart_groups[uart].UARTLCR_H.SetBits(_UART_UARTLCR_H_FEN)
+	} else {
+		uart_groups[uart].UARTLCR_H.ClearBits(_UART_UARTLCR_H_FEN)
+	}
+}
+
+// Assert a break condition on the TX line, which holds it low, or release it
+//
+func UART_set_break(uart uint32, en bool) {
+	assert(uart < NUM_UARTS)
+	if en {
+		uart_groups[uart].UARTLCR_H.SetBits(_UART_UARTLCR_H_BRK)
+	} else {
+		uart_groups[uart].UARTLCR_H.ClearBits(_UART_UARTLCR_H_BRK)
+	}
+}
+
+// Set which UART interrupts are enabled
+//
+//go:inline
+func UART_set_irq_mask(uart uint32, mask UART_irq_t) {
+	assert(uart < NUM_UARTS)
+	uart_groups[uart].UARTIMSC.Set(uint32(mask))
+}
+
+// Return which UART interrupts are enabled
+//
+//go:inline
+func UART_get_irq_mask(uart uint32) UART_irq_t {
+	assert(uart < NUM_UARTS)
+	return UART_irq_t(uart_groups[uart].UARTIMSC.Get())
+}
+
+// Return which enabled UART interrupts are pending
+//
+//go:inline
+func UART_get_irq_status(uart uint32) UART_irq_t {
+	assert(uart < NUM_UARTS)
+	return UART_irq_t(uart_groups[uart].UARTMIS.Get())
+}
+
+// Clear UART interrupts. The FIFO interrupts are also cleared by reading or
+// writing the FIFO.
+//
+//go:inline
+func UART_clear_irq(uart uint32, mask UART_irq_t) {
+	assert(uart < NUM_UARTS)
+	uart_groups[uart].UARTICR.Set(uint32(mask))
+}
+
+// Set the FIFO levels at which the RX and TX interrupts are asserted, which
+// are one of the UART_FIFO_LEVEL values
+//
+func UART_set_fifo_levels(uart, rx, tx uint32) {
+	assert(uart < NUM_UARTS)
+	assert(rx <= UART_FIFO_LEVEL_7_8 && tx <= UART_FIFO_LEVEL_7_8)
+	uart_groups[uart].UARTIFLS.Set(rx<<rp.UART0_UARTIFLS_RXIFLSEL_Pos | tx<<rp.UART0_UARTIFLS_TXIFLSEL_Pos)
+}
+
+// Pop a byte from the RX FIFO, which should be readable. The byte is in the
+// lower eight bits, with the UART_DATA error flags above.
+//
+//go:inline
+func UART_get_data(uart uint32) uint32 {
+	assert(uart < NUM_UARTS)
+	return uart_groups[uart].UARTDR.Get()
+}
+
+// Push a byte to the TX FIFO, which should be writable
+//
+//go:inline
+func UART_put_data(uart uint32, v uint8) {
+	assert(uart < NUM_UARTS)
+	uart_groups[uart].UARTDR.Set(uint32(v))
+}
+
+// Determine if space is available in the TX FIFO
+//
+//go:inline
+func UART_is_writable(uart uint32) bool {
+	assert(uart < NUM_UARTS)
+	return uart_is_writable(uart_groups[uart])
+}
+
+// Determine whether data is waiting in the RX FIFO
+//
+//go:inline
+func UART_is_readable(uart uint32) bool {
+	assert(uart < NUM_UARTS)
+	return uart_is_readable(uart_groups[uart])
+}
+
+// Wait for the TX FIFO to be drained, and the last byte to be sent
+//
+func UART_tx_wait_blocking(uart uint32) {
+	assert(uart < NUM_UARTS)
+	uart_tx_wait_blocking(uart_groups[uart])
+}
+
+// Write bytes to the UART, blocking until they are all in the TX FIFO
+//
+func UART_write_blocking(uart uint32, w []uint8) {
+	assert(uart < NUM_UARTS)
+	uart_write_blocking(uart_groups[uart], w)
+}
+
+// Read bytes from the UART, blocking until the buffer is full
+//
+func UART_read_blocking(uart uint32, r []uint8) {
+	assert(uart < NUM_UARTS)
+	uart_read_blocking(uart_groups[uart], r)
+}
+
+// Write a byte, blocking until there is space in the TX FIFO
+//
+//go:inline
+func UART_putc_raw(uart uint32, c uint8) {
+	assert(uart < NUM_UARTS)
+	uart_write_blocking(uart_groups[uart], []uint8{c})
+}
+
+// Read a byte, blocking until one is received
+//
+func UART_getc(uart uint32) uint8 {
+	var c [1]uint8
+	UART_read_blocking(uart, c[:])
+	return c[0]
+}
@@ -0,0 +1,56 @@
+package sdk
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	UART_MIN_IBRD = 1     // Minimum integer divisor
+	UART_MAX_IBRD = 65535 // Maximum integer divisor
+	UART_MAX_FBRD = 63    // Maximum fractional divisor, in 64ths
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the integer and fractional divisors for a baud rate given the
+// peripheral clock frequency freq_in, and the achieved rate. The divisor is
+// freq_in/(16*baudrate), and the fractional part is in 64ths, rounded to the
+// nearest. Rates which are too high or too low are clamped. A baud rate of
+// zero returns zero values.
+//
+// The Pico SDK 1.4.0 can round the fractional divisor up to 64, which does
+// not fit in the six bits of UARTFBRD. Here it is carried into the integer
+// divisor instead.
+func UART_baudrate_solve(freq_in, baudrate uint32) (uint32, uint32, uint32) {
+	if baudrate == 0 || freq_in == 0 {
+		return 0, 0, 0
+	}
+
+	// The divisor in 128ths, so that the fractional part can be rounded
+	baud_rate_div := 8 * uint64(freq_in) / uint64(baudrate)
+	ibrd := baud_rate_div >> 7
+	fbrd := uint64(0)
+	switch {
+	case ibrd < UART_MIN_IBRD:
+		ibrd = UART_MIN_IBRD
+	case ibrd >= UART_MAX_IBRD:
+		ibrd = UART_MAX_IBRD
+	default:
+		fbrd = ((baud_rate_div & 0x7F) + 1) / 2
+		if fbrd > UART_MAX_FBRD {
+			ibrd, fbrd = ibrd+1, 0
+		}
+	}
+
+	// Return the values and the frequency we were able to achieve
+	return uint32(ibrd), uint32(fbrd), UART_baudrate_for(freq_in, uint32(ibrd), uint32(fbrd))
+}
+
+// Return the baud rate for integer and fractional divisors given the
+// peripheral clock frequency freq_in
+func UART_baudrate_for(freq_in, ibrd, fbrd uint32) uint32 {
+	if ibrd == 0 {
+		return 0
+	}
+	return uint32(4 * uint64(freq_in) / (64*uint64(ibrd) + uint64(fbrd)))
+}
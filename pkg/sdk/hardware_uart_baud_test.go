@@ -0,0 +1,77 @@
+package sdk_test
+
+import (
+	"testing"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+func Test_UART_Baud_001(t *testing.T) {
+	// Values which match the Pico SDK
+	tests := []struct {
+		freq, baud           uint32
+		ibrd, fbrd, achieved uint32
+	}{
+		{125_000_000, 115_200, 67, 52, 115_207},
+		{125_000_000, 9_600, 813, 51, 9_600},
+		{125_000_000, 921_600, 8, 31, 920_810},
+		{125_000_000, 1_000_000, 7, 52, 1_000_000},
+		{125_000_000, 300, 26041, 43, 299},
+		{48_000_000, 115_200, 26, 3, 115_176},
+		{133_000_000, 115_200, 72, 10, 115_201},
+	}
+	for _, test := range tests {
+		ibrd, fbrd, achieved := UART_baudrate_solve(test.freq, test.baud)
+		if ibrd != test.ibrd || fbrd != test.fbrd || achieved != test.achieved {
+			t.Errorf("UART_baudrate_solve(%d, %d) = %d, %d, %d, expected %d, %d, %d", test.freq, test.baud, ibrd, fbrd, achieved, test.ibrd, test.fbrd, test.achieved)
+		}
+		if rate := UART_baudrate_for(test.freq, ibrd, fbrd); rate != achieved {
+			t.Errorf("UART_baudrate_for(%d, %d, %d) = %d, expected %d", test.freq, ibrd, fbrd, rate, achieved)
+		}
+	}
+}
+
+func Test_UART_Baud_002(t *testing.T) {
+	// Rates which are clamped
+	tests := []struct {
+		freq, baud           uint32
+		ibrd, fbrd, achieved uint32
+	}{
+		{125_000_000, 10_000_000, UART_MIN_IBRD, 0, 7_812_500},
+		{125_000_000, 100, UART_MAX_IBRD, 0, 119},
+		{125_000_000, 0, 0, 0, 0},
+		{0, 115_200, 0, 0, 0},
+	}
+	for _, test := range tests {
+		ibrd, fbrd, achieved := UART_baudrate_solve(test.freq, test.baud)
+		if ibrd != test.ibrd || fbrd != test.fbrd || achieved != test.achieved {
+			t.Errorf("UART_baudrate_solve(%d, %d) = %d, %d, %d, expected %d, %d, %d", test.freq, test.baud, ibrd, fbrd, achieved, test.ibrd, test.fbrd, test.achieved)
+		}
+	}
+}
+
+func Test_UART_Baud_003(t *testing.T) {
+	// A fractional divisor which rounds up to 64 is carried into the integer
+	// divisor, where the Pico SDK would overflow UARTFBRD
+	tests := []struct {
+		freq, baud           uint32
+		ibrd, fbrd, achieved uint32
+	}{
+		{125_000_000, 1_201, 6505, 0, 1_200},
+		{48_000_000, 1_228, 2443, 0, 1_227},
+	}
+	for _, test := range tests {
+		ibrd, fbrd, achieved := UART_baudrate_solve(test.freq, test.baud)
+		if ibrd != test.ibrd || fbrd != test.fbrd || achieved != test.achieved {
+			t.Errorf("UART_baudrate_solve(%d, %d) = %d, %d, %d, expected %d, %d, %d", test.freq, test.baud, ibrd, fbrd, achieved, test.ibrd, test.fbrd, test.achieved)
+		}
+	}
+
+	// The fractional divisor never exceeds six bits
+	for baud := uint32(1_200); baud < 3_000_000; baud += 7 {
+		if _, fbrd, _ := UART_baudrate_solve(125_000_000, baud); fbrd > UART_MAX_FBRD {
+			t.Fatalf("UART_baudrate_solve(125000000, %d) fbrd = %d", baud, fbrd)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package sdk
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// uart_hw_t is the register block of a PL011 UART
+type uart_hw_t struct {
+	UARTDR    register32 // 0x0
+	UARTRSR   register32 // 0x4
+	_         [16]byte
+	UARTFR    register32 // 0x18
+	_         [4]byte
+	UARTILPR  register32 // 0x20
+	UARTIBRD  register32 // 0x24
+	UARTFBRD  register32 // 0x28
+	UARTLCR_H register32 // 0x2C
+	UARTCR    register32 // 0x30
+	UARTIFLS  register32 // 0x34
+	UARTIMSC  register32 // 0x38
+	UARTRIS   register32 // 0x3C
+	UARTMIS   register32 // 0x40
+	UARTICR   register32 // 0x44
+	UARTDMACR register32 // 0x48
+}
+
+type UART_parity_t uint32
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	UART_PARITY_NONE UART_parity_t = iota
+	UART_PARITY_EVEN
+	UART_PARITY_ODD
+)
+
+const (
+	UART_FIFO_DEPTH    = 32 // Depth of the TX and RX FIFOs
+	UART_MIN_DATA_BITS = 5
+	UART_MAX_DATA_BITS = 8
+	UART_MIN_STOP_BITS = 1
+	UART_MAX_STOP_BITS = 2
+)
+
+// Register bits, which are the same as in device/rp but are repeated here
+// so that they can be tested on the host
+const (
+	_UART_UARTDR_DATA_MSK      = 0xFF
+	_UART_UARTDR_ERR_MSK       = 0xF00 // Framing, parity, break and overrun errors
+	_UART_UARTFR_BUSY          = 1 << 3
+	_UART_UARTFR_RXFE          = 1 << 4
+	_UART_UARTFR_TXFF          = 1 << 5
+	_UART_UARTLCR_H_BRK        = 1 << 0
+	_UART_UARTLCR_H_PEN        = 1 << 1
+	_UART_UARTLCR_H_EPS        = 1 << 2
+	_UART_UARTLCR_H_STP2       = 1 << 3
+	_UART_UARTLCR_H_FEN        = 1 << 4
+	_UART_UARTLCR_H_WLEN_POS   = 5
+	_UART_UARTLCR_H_WLEN_MSK   = 3 << 5
+	_UART_UARTCR_UARTEN        = 1 << 0
+	_UART_UARTCR_TXE           = 1 << 8
+	_UART_UARTCR_RXE           = 1 << 9
+	_UART_UARTCR_RTSEN         = 1 << 14
+	_UART_UARTCR_CTSEN         = 1 << 15
+	_UART_UARTDMACR_RXDMAE     = 1 << 0
+	_UART_UARTDMACR_TXDMAE     = 1 << 1
+	_UART_UARTLCR_H_FORMAT_MSK = _UART_UARTLCR_H_WLEN_MSK | _UART_UARTLCR_H_STP2 | _UART_UARTLCR_H_PEN | _UART_UARTLCR_H_EPS
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Return the LCR_H bits for a data format
+func uart_format_bits(data_bits, stop_bits uint32, parity UART_parity_t) uint32 {
+	v := (data_bits - UART_MIN_DATA_BITS) << _UART_UARTLCR_H_WLEN_POS
+	if stop_bits == 2 {
+		v |= _UART_UARTLCR_H_STP2
+	}
+	if parity != UART_PARITY_NONE {
+		v |= _UART_UARTLCR_H_PEN
+	}
+	if parity == UART_PARITY_EVEN {
+		v |= _UART_UARTLCR_H_EPS
+	}
+	return v
+}
+
+// Set the divisors, and then write LCR_H which latches them
+func uart_set_divisors(hw *uart_hw_t, ibrd, fbrd uint32) {
+	hw.UARTIBRD.Set(ibrd)
+	hw.UARTFBRD.Set(fbrd)
+	hw.UARTLCR_H.SetBits(0)
+}
+
+// Enable or disable CTS and RTS flow control. The UART is disabled while the
+// control register is changed, and then restored.
+func uart_set_hw_flow(hw *uart_hw_t, cts, rts bool) {
+	v := uint32(0)
+	if cts {
+		v |= _UART_UARTCR_CTSEN
+	}
+	if rts {
+		v |= _UART_UARTCR_RTSEN
+	}
+	cr := hw.UARTCR.Get()
+	hw.UARTCR.Set(cr &^ _UART_UARTCR_UARTEN)
+	hw.UARTCR.Set(cr&^(_UART_UARTCR_CTSEN|_UART_UARTCR_RTSEN) | v)
+}
+
+//go:inline
+func uart_is_writable(hw *uart_hw_t) bool {
+	return !hw.UARTFR.HasBits(_UART_UARTFR_TXFF)
+}
+
+//go:inline
+func uart_is_readable(hw *uart_hw_t) bool {
+	return !hw.UARTFR.HasBits(_UART_UARTFR_RXFE)
+}
+
+// Write bytes, blocking until they are all in the TX FIFO
+func uart_write_blocking(hw *uart_hw_t, w []uint8) {
+	for _, v := range w {
+		for !uart_is_writable(hw) {
+		}
+		hw.UARTDR.Set(uint32(v))
+	}
+}
+
+// Read bytes, blocking until the buffer is full. Bytes received with errors
+// are returned as they were received.
+func uart_read_blocking(hw *uart_hw_t, r []uint8) {
+	for i := range r {
+		for !uart_is_readable(hw) {
+		}
+		r[i] = uint8(hw.UARTDR.Get() & _UART_UARTDR_DATA_MSK)
+	}
+}
+
+// Wait for the TX FIFO to be drained, and the last byte to be sent
+func uart_tx_wait_blocking(hw *uart_hw_t) {
+	for hw.UARTFR.HasBits(_UART_UARTFR_BUSY) {
+	}
+}
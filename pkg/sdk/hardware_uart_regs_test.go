@@ -0,0 +1,141 @@
+package sdk
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func Test_UART_Regs_001(t *testing.T) {
+	// Check the register layout matches the datasheet
+	var hw uart_hw_t
+	if size := unsafe.Sizeof(hw); size != 0x4C {
+		t.Errorf("Unexpected size 0x%X", size)
+	}
+	offsets := []struct {
+		name   string
+		offset uintptr
+		want   uintptr
+	}{
+		{"UARTDR", unsafe.Offsetof(hw.UARTDR), 0x0},
+		{"UARTFR", unsafe.Offsetof(hw.UARTFR), 0x18},
+		{"UARTIBRD", unsafe.Offsetof(hw.UARTIBRD), 0x24},
+		{"UARTFBRD", unsafe.Offsetof(hw.UARTFBRD), 0x28},
+		{"UARTLCR_H", unsafe.Offsetof(hw.UARTLCR_H), 0x2C},
+		{"UARTCR", unsafe.Offsetof(hw.UARTCR), 0x30},
+		{"UARTIMSC", unsafe.Offsetof(hw.UARTIMSC), 0x38},
+		{"UARTICR", unsafe.Offsetof(hw.UARTICR), 0x44},
+		{"UARTDMACR", unsafe.Offsetof(hw.UARTDMACR), 0x48},
+	}
+	for _, o := range offsets {
+		if o.offset != o.want {
+			t.Errorf("%s: offset 0x%X, expected 0x%X", o.name, o.offset, o.want)
+		}
+	}
+}
+
+func Test_UART_Regs_002(t *testing.T) {
+	// Line control bits for data formats
+	tests := []struct {
+		data, stop uint32
+		parity     UART_parity_t
+		want       uint32
+	}{
+		{8, 1, UART_PARITY_NONE, 0x60},
+		{7, 1, UART_PARITY_EVEN, 0x46},
+		{7, 2, UART_PARITY_ODD, 0x4A},
+		{5, 1, UART_PARITY_NONE, 0x00},
+		{8, 2, UART_PARITY_EVEN, 0x6E},
+	}
+	for _, test := range tests {
+		if v := uart_format_bits(test.data, test.stop, test.parity); v != test.want {
+			t.Errorf("uart_format_bits(%d, %d, %d) = 0x%02X, expected 0x%02X", test.data, test.stop, test.parity, v, test.want)
+		}
+		if v := uart_format_bits(test.data, test.stop, test.parity); v&^_UART_UARTLCR_H_FORMAT_MSK != 0 {
+			t.Errorf("uart_format_bits(%d, %d, %d) = 0x%02X, outside mask", test.data, test.stop, test.parity, v)
+		}
+	}
+}
+
+func Test_UART_Regs_003(t *testing.T) {
+	// Writing the divisors latches them with a write to LCR_H
+	var hw uart_hw_t
+	latched := false
+	register_fakes[&hw.UARTLCR_H] = register_fake{
+		set: func(v uint32) {
+			latched = hw.UARTIBRD.Reg == 67 && hw.UARTFBRD.Reg == 52
+		},
+	}
+	defer delete(register_fakes, &hw.UARTLCR_H)
+	uart_set_divisors(&hw, 67, 52)
+	if !latched {
+		t.Error("Expected LCR_H write after divisors")
+	}
+}
+
+func Test_UART_Regs_004(t *testing.T) {
+	// Loopback, where each byte written is received, with errors flagged in
+	// the upper bits of the data register
+	var hw uart_hw_t
+	var fifo []uint32
+	register_fakes[&hw.UARTDR] = register_fake{
+		get: func() uint32 {
+			v := fifo[0]
+			fifo = fifo[1:]
+			return v | _UART_UARTDR_ERR_MSK
+		},
+		set: func(v uint32) {
+			fifo = append(fifo, v)
+		},
+	}
+	register_fakes[&hw.UARTFR] = register_fake{
+		get: func() uint32 {
+			v := uint32(0)
+			if len(fifo) == 0 {
+				v |= _UART_UARTFR_RXFE
+			}
+			if len(fifo) >= UART_FIFO_DEPTH {
+				v |= _UART_UARTFR_TXFF
+			}
+			return v
+		},
+	}
+	defer delete(register_fakes, &hw.UARTDR)
+	defer delete(register_fakes, &hw.UARTFR)
+
+	uart_write_blocking(&hw, []uint8("hello"))
+	r := make([]uint8, 5)
+	uart_read_blocking(&hw, r)
+	if string(r) != "hello" {
+		t.Errorf("Unexpected read %q", r)
+	}
+	if uart_is_readable(&hw) {
+		t.Error("Expected RX FIFO to be empty")
+	}
+}
+
+func Test_UART_Regs_005(t *testing.T) {
+	// Flow control bits are changed with the UART disabled, and other bits
+	// are preserved
+	var hw uart_hw_t
+	hw.UARTCR.Reg = _UART_UARTCR_UARTEN | _UART_UARTCR_TXE | _UART_UARTCR_RXE
+	var writes []uint32
+	register_fakes[&hw.UARTCR] = register_fake{
+		set: func(v uint32) {
+			writes = append(writes, v)
+			hw.UARTCR.Reg = v
+		},
+	}
+	defer delete(register_fakes, &hw.UARTCR)
+
+	uart_set_hw_flow(&hw, true, true)
+	if len(writes) != 2 || writes[0]&_UART_UARTCR_UARTEN != 0 {
+		t.Errorf("Expected UART to be disabled first, writes %04X", writes)
+	}
+	if hw.UARTCR.Reg != _UART_UARTCR_UARTEN|_UART_UARTCR_TXE|_UART_UARTCR_RXE|_UART_UARTCR_CTSEN|_UART_UARTCR_RTSEN {
+		t.Errorf("Unexpected UARTCR 0x%04X", hw.UARTCR.Reg)
+	}
+	uart_set_hw_flow(&hw, false, true)
+	if hw.UARTCR.Reg != _UART_UARTCR_UARTEN|_UART_UARTCR_TXE|_UART_UARTCR_RXE|_UART_UARTCR_RTSEN {
+		t.Errorf("Unexpected UARTCR 0x%04X", hw.UARTCR.Reg)
+	}
+}
@@ -1,5 +1,9 @@
 package sdk
 
+import (
+	"time"
+)
+
 //go:inline
 func bool_to_bit(v bool) uint32 {
 	if v {
@@ -7,3 +11,25 @@ func bool_to_bit(v bool) uint32 {
 	}
 	return 0
 }
+
+//go:inline
+func min_int(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Poll until cond returns true, and return false if it does not within
+// the timeout. The condition is always checked at least once.
+func wait_until(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if cond() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+	}
+}
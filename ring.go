@@ -0,0 +1,89 @@
+package pico
+
+import (
+	"sync/atomic"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ring is a fixed-size buffer of values, which is written by an interrupt
+// handler and read by user code. One slot is kept free in order to tell a
+// full buffer from an empty one.
+type ring struct {
+	buf  []uint16
+	head uint32 // Next slot to write
+	tail uint32 // Next slot to read
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Create a ring buffer which holds up to size values
+func _NewRing(size int) *ring {
+	return &ring{buf: make([]uint16, size+1)}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Return the number of values in the buffer
+func (r *ring) len() int {
+	head, tail := atomic.LoadUint32(&r.head), atomic.LoadUint32(&r.tail)
+	if head >= tail {
+		return int(head - tail)
+	}
+	return int(head + uint32(len(r.buf)) - tail)
+}
+
+// Return the number of values which can be written before the buffer is full
+func (r *ring) free() int {
+	return len(r.buf) - 1 - r.len()
+}
+
+// Write a value into the buffer, returns false if the buffer is full
+func (r *ring) push(v uint16) bool {
+	head := atomic.LoadUint32(&r.head)
+	next := head + 1
+	if next == uint32(len(r.buf)) {
+		next = 0
+	}
+	if next == atomic.LoadUint32(&r.tail) {
+		return false
+	}
+	r.buf[head] = v
+	atomic.StoreUint32(&r.head, next)
+	return true
+}
+
+// Read a value from the buffer, returns false if the buffer is empty
+func (r *ring) pop() (uint16, bool) {
+	tail := atomic.LoadUint32(&r.tail)
+	if tail == atomic.LoadUint32(&r.head) {
+		return 0, false
+	}
+	v := r.buf[tail]
+	if tail++; tail == uint32(len(r.buf)) {
+		tail = 0
+	}
+	atomic.StoreUint32(&r.tail, tail)
+	return v, true
+}
+
+// Read values into buf, and return the number of values read
+func (r *ring) read(buf []uint16) int {
+	for i := range buf {
+		if v, ok := r.pop(); !ok {
+			return i
+		} else {
+			buf[i] = v
+		}
+	}
+	return len(buf)
+}
+
+// Discard all values in the buffer. Should only be called when the writer is
+// not active.
+func (r *ring) reset() {
+	atomic.StoreUint32(&r.tail, atomic.LoadUint32(&r.head))
+}
@@ -0,0 +1,111 @@
+package pico
+
+import (
+	"testing"
+)
+
+func Test_Ring_001(t *testing.T) {
+	r := _NewRing(4)
+	if r.len() != 0 {
+		t.Error("Expected empty buffer, got", r.len())
+	}
+	if r.free() != 4 {
+		t.Error("Expected 4 free, got", r.free())
+	}
+	if _, ok := r.pop(); ok {
+		t.Error("Expected pop from empty buffer to fail")
+	}
+}
+
+func Test_Ring_002(t *testing.T) {
+	r := _NewRing(4)
+	for i := uint16(0); i < 4; i++ {
+		if !r.push(i) {
+			t.Fatal("Unexpected full buffer at", i)
+		}
+		if r.free() != int(3-i) {
+			t.Error("Expected", 3-i, "free, got", r.free())
+		}
+	}
+	if r.push(4) {
+		t.Error("Expected push to full buffer to fail")
+	}
+	if r.len() != 4 {
+		t.Error("Expected 4 values, got", r.len())
+	}
+	for i := uint16(0); i < 4; i++ {
+		if v, ok := r.pop(); !ok || v != i {
+			t.Error("Expected", i, "got", v, ok)
+		}
+	}
+	if r.len() != 0 || r.free() != 4 {
+		t.Error("Expected empty buffer, got", r.len(), r.free())
+	}
+}
+
+func Test_Ring_003(t *testing.T) {
+	// Push and pop across the end of the buffer several times
+	r := _NewRing(3)
+	next := uint16(0)
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 2; i++ {
+			if !r.push(next + uint16(i)) {
+				t.Fatal("Unexpected full buffer in round", round)
+			}
+		}
+		buf := make([]uint16, 3)
+		if n := r.read(buf); n != 2 {
+			t.Fatal("Expected 2 values, got", n)
+		} else if buf[0] != next || buf[1] != next+1 {
+			t.Error("Unexpected values", buf[:n], "in round", round)
+		}
+		next += 2
+	}
+	if r.len() != 0 {
+		t.Error("Expected empty buffer, got", r.len())
+	}
+}
+
+func Test_Ring_004(t *testing.T) {
+	// The length is correct when head has wrapped behind tail
+	r := _NewRing(3)
+	r.push(1)
+	r.push(2)
+	r.push(3)
+	r.pop()
+	r.pop()
+	r.push(4)
+	r.push(5)
+	if r.len() != 3 || r.free() != 0 {
+		t.Error("Expected full buffer, got", r.len(), r.free())
+	}
+	buf := make([]uint16, 4)
+	if n := r.read(buf); n != 3 {
+		t.Error("Expected 3 values, got", n)
+	} else if buf[0] != 3 || buf[1] != 4 || buf[2] != 5 {
+		t.Error("Unexpected values", buf[:n])
+	}
+}
+
+func Test_Ring_005(t *testing.T) {
+	r := _NewRing(4)
+	r.push(1)
+	r.push(2)
+	r.push(3)
+	r.reset()
+	if r.len() != 0 || r.free() != 4 {
+		t.Error("Expected empty buffer after reset, got", r.len(), r.free())
+	}
+	if _, ok := r.pop(); ok {
+		t.Error("Expected pop after reset to fail")
+	}
+	// The buffer is usable again after a reset
+	for i := uint16(10); i < 14; i++ {
+		if !r.push(i) {
+			t.Fatal("Unexpected full buffer at", i)
+		}
+	}
+	if v, ok := r.pop(); !ok || v != 10 {
+		t.Error("Expected 10, got", v, ok)
+	}
+}
@@ -0,0 +1,61 @@
+package pico
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SPIMode determines the clock polarity (CPOL) and phase (CPHA)
+type SPIMode uint8
+
+// SPIOrder determines whether the most or least significant bit of each
+// frame is sent first
+type SPIOrder uint8
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	SPIMode0 SPIMode = iota // CPOL=0 CPHA=0
+	SPIMode1                // CPOL=0 CPHA=1
+	SPIMode2                // CPOL=1 CPHA=0
+	SPIMode3                // CPOL=1 CPHA=1
+)
+
+const (
+	SPIMSBFirst SPIOrder = iota
+	SPILSBFirst
+)
+
+const (
+	SPI_MIN_DATA_BITS = 4  // Minimum number of bits in a frame
+	SPI_MAX_DATA_BITS = 16 // Maximum number of bits in a frame
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Return the clock polarity, which is true when the clock idles high
+func (m SPIMode) CPOL() bool {
+	return m&2 != 0
+}
+
+// Return the clock phase, which is true when data is sampled on the second
+// clock edge
+func (m SPIMode) CPHA() bool {
+	return m&1 != 0
+}
+
+// Return a frame of bits in the order which the hardware sends it. The
+// hardware always sends the most significant bit first, so for SPILSBFirst
+// the bits are reversed. The same function converts received frames back.
+func (o SPIOrder) Frame(v uint16, bits uint8) uint16 {
+	v &= uint16(1<<bits - 1)
+	if o != SPILSBFirst {
+		return v
+	}
+	r := uint16(0)
+	for i := uint8(0); i < bits; i++ {
+		r = r<<1 | v&1
+		v >>= 1
+	}
+	return r
+}
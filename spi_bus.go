@@ -0,0 +1,283 @@
+//go:build pico
+
+package pico
+
+import (
+	"runtime"
+	"sync"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SPIBus is an SPI interface shared between several devices, each with its
+// own chip select pin and format. Transactions are locked between goroutines
+// and between cores.
+type SPIBus struct {
+	spi   *SPI
+	mu    sync.Mutex
+	lock  uint32 // Hardware spin lock, which protects owned
+	owned bool   // True while a transaction is in progress on either core
+	baud  uint32 // Requested baud rate
+}
+
+// SPIDevice is a device on a shared bus. The baud rate and format are set on
+// the bus at the start of each transaction, and the CS pin is asserted (low)
+// for the duration of the transaction. It satisfies the drivers.SPI interface.
+type SPIDevice struct {
+	bus   *SPIBus
+	cs    Pin
+	baud  uint32
+	mode  SPIMode
+	order SPIOrder
+	bits  uint8
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	spi_bus [NUM_SPIS]*SPIBus
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Return the shared bus for the SPI interface on a pin, which is the RX pin
+// as for Pin.SPI. The same bus is returned for each pin of an interface.
+func NewSPIBus(pin Pin) (*SPIBus, error) {
+	// Return existing bus
+	if config, exists := map_spi[pin]; !exists {
+		return nil, ErrBadParameter.With("NewSPIBus:", pin)
+	} else if bus := spi_bus[config.Num]; bus != nil {
+		return bus, nil
+	}
+
+	// Claim a hardware spin lock, for locking between cores
+	lock, ok := Spin_lock_claim_unused()
+	if !ok {
+		return nil, ErrUnexpectedValue.With("NewSPIBus: no spin locks")
+	}
+
+	// Initialise the interface and the SCK, TX and RX pins. The chip select
+	// pin of the interface is not used, as each device has its own.
+	spi, err := _GPIO.spi(pin, false)
+	if err != nil {
+		Spin_lock_unclaim(lock)
+		return nil, err
+	}
+
+	// Return the bus
+	bus := &SPIBus{spi: spi, lock: lock, baud: spi.Baud}
+	spi_bus[spi.Num] = bus
+	return bus, nil
+}
+
+// Return a device on the bus with a chip select pin and baud rate. The device
+// uses mode 0 with 8-bit frames until SetFormat is called. A baud rate of zero
+// uses SPI_DEFAULT_BAUD_RATE.
+func (b *SPIBus) NewDevice(cs Pin, baud uint32) (*SPIDevice, error) {
+	if err := assert(cs < NUM_BANK0_GPIOS, ErrBadParameter.With("NewDevice:", cs)); err != nil {
+		return nil, err
+	}
+	if baud == 0 {
+		baud = SPI_DEFAULT_BAUD_RATE
+	}
+
+	// Deassert chip select
+	if err := cs.SetMode(ModeOutput); err != nil {
+		return nil, err
+	}
+	cs.Set(true)
+
+	// Return the device
+	return &SPIDevice{
+		bus:   b,
+		cs:    cs,
+		baud:  baud,
+		mode:  SPIMode0,
+		order: SPIMSBFirst,
+		bits:  SPI_DEFAULT_DATA_BITS,
+	}, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - BUS
+
+// Return the SPI interface number
+func (b *SPIBus) Num() uint32 {
+	return b.spi.Num
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - DEVICE
+
+// Set the mode, bit order and number of bits in each frame, which is between
+// SPI_MIN_DATA_BITS and SPI_MAX_DATA_BITS
+func (d *SPIDevice) SetFormat(mode SPIMode, order SPIOrder, bits uint8) error {
+	if err := spi_check_format(mode, order, bits); err != nil {
+		return err
+	}
+	d.mode, d.order, d.bits = mode, order, bits
+	return nil
+}
+
+// Return the mode, bit order and number of bits in each frame
+func (d *SPIDevice) Format() (SPIMode, SPIOrder, uint8) {
+	return d.mode, d.order, d.bits
+}
+
+// Set the requested baud rate, which is applied on the next transaction
+func (d *SPIDevice) SetBaud(baud uint32) error {
+	if err := assert(baud > 0, ErrBadParameter.With("SetBaud:", baud)); err != nil {
+		return err
+	}
+	d.baud = baud
+	return nil
+}
+
+// Return the requested baud rate
+func (d *SPIDevice) Baud() uint32 {
+	return d.baud
+}
+
+// Write w and read into r in a single transaction. Either can be nil, in which
+// case zeros are written or data read is discarded. If both are set they must
+// be the same length.
+func (d *SPIDevice) Tx(w, r []byte) error {
+	if err := assert(w == nil || r == nil || len(w) == len(r), ErrBadParameter.With("Tx")); err != nil {
+		return err
+	}
+	spi := d.begin()
+	defer d.end()
+	switch {
+	case w == nil:
+		spi.read(r)
+	case r == nil:
+		spi.write(w)
+	default:
+		spi.tx(w, r)
+	}
+	return nil
+}
+
+// Write and read a single byte in a transaction
+func (d *SPIDevice) Transfer(b byte) (byte, error) {
+	var buf [1]byte
+	if err := d.Tx([]byte{b}, buf[:]); err != nil {
+		return 0, err
+	}
+	return buf[0], nil
+}
+
+// Write bytes in a transaction, and return the number of bytes written
+func (d *SPIDevice) Write(w []byte) (int, error) {
+	if err := d.Tx(w, nil); err != nil {
+		return 0, err
+	}
+	return len(w), nil
+}
+
+// Read bytes in a transaction while writing zeros, and return the number of
+// bytes read
+func (d *SPIDevice) Read(r []byte) (int, error) {
+	if err := d.Tx(nil, r); err != nil {
+		return 0, err
+	}
+	return len(r), nil
+}
+
+// Write w and read into r in a single transaction, for frames of more than
+// eight bits. Either can be nil, in which case zeros are written or data read
+// is discarded. If both are set they must be the same length.
+func (d *SPIDevice) Tx16(w, r []uint16) error {
+	if err := assert(w == nil || r == nil || len(w) == len(r), ErrBadParameter.With("Tx16")); err != nil {
+		return err
+	}
+	spi := d.begin()
+	defer d.end()
+	switch {
+	case w == nil:
+		spi.read16(r)
+	case r == nil:
+		spi.write16(w)
+	default:
+		spi.tx16(w, r)
+	}
+	return nil
+}
+
+// Write half words in a transaction, and return the number written
+func (d *SPIDevice) Write16(w []uint16) (int, error) {
+	if err := d.Tx16(w, nil); err != nil {
+		return 0, err
+	}
+	return len(w), nil
+}
+
+// Read half words in a transaction while writing zeros, and return the
+// number read
+func (d *SPIDevice) Read16(r []uint16) (int, error) {
+	if err := d.Tx16(nil, r); err != nil {
+		return 0, err
+	}
+	return len(r), nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Lock the bus, apply the settings of the device and assert chip select.
+// Returns the interface for the transaction.
+func (d *SPIDevice) begin() *SPI {
+	b := d.bus
+	b.lock_bus()
+
+	// Apply the baud rate and format if they have changed
+	if b.baud != d.baud {
+		b.spi.Baud = SPI_set_baudrate(b.spi.Num, d.baud)
+		b.baud = d.baud
+	}
+	if mode, order, bits := b.spi.Format(); mode != d.mode || order != d.order || bits != d.bits {
+		b.spi.SetFormat(d.mode, d.order, d.bits)
+	}
+
+	// Assert chip select
+	d.cs.Set(false)
+	return b.spi
+}
+
+// Deassert chip select and unlock the bus
+func (d *SPIDevice) end() {
+	d.cs.Set(true)
+	d.bus.unlock_bus()
+}
+
+// Lock the bus between goroutines with the mutex, and then between cores.
+// The spin lock is only held while the owned flag is tested and set, so that
+// the other core is not blocked with interrupts disabled during a transaction.
+func (b *SPIBus) lock_bus() {
+	b.mu.Lock()
+	for {
+		state := Spin_lock_blocking(b.lock)
+		acquired := !b.owned
+		b.owned = true
+		Spin_unlock(b.lock, state)
+		if acquired {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+// Unlock the bus between cores and goroutines
+func (b *SPIBus) unlock_bus() {
+	state := Spin_lock_blocking(b.lock)
+	b.owned = false
+	Spin_unlock(b.lock, state)
+	b.mu.Unlock()
+}
@@ -17,5 +17,51 @@ func (v *SPI) String() string {
 	str += fmt.Sprint(" tx=", v.TX)
 	str += fmt.Sprint(" sck=", v.SCK)
 	str += fmt.Sprint(" cs=", v.CS)
+	str += fmt.Sprint(" mode=", v.mode)
+	if v.order == SPILSBFirst {
+		str += " lsb"
+	}
+	str += fmt.Sprint(" bits=", v.bits)
+	if v.dma {
+		str += fmt.Sprint(" dma=", v.dma_tx, ",", v.dma_rx)
+	}
+	return str + ">"
+}
+
+func (v *SPIBus) String() string {
+	str := "<spibus"
+	str += fmt.Sprint(" spi=", v.spi)
+	str += fmt.Sprint(" lock=", v.lock)
+	return str + ">"
+}
+
+func (v *SPIDevice) String() string {
+	str := "<spidevice"
+	str += fmt.Sprint(" num=", v.bus.spi.Num)
+	str += fmt.Sprint(" cs=", v.cs)
+	str += fmt.Sprint(" baud=", v.baud)
+	str += fmt.Sprint(" mode=", v.mode)
+	if v.order == SPILSBFirst {
+		str += " lsb"
+	}
+	str += fmt.Sprint(" bits=", v.bits)
+	return str + ">"
+}
+
+func (v *SPISlave) String() string {
+	str := "<spislave"
+	str += fmt.Sprint(" num=", v.Num)
+	str += fmt.Sprint(" rx=", v.RX)
+	str += fmt.Sprint(" tx=", v.TX)
+	str += fmt.Sprint(" sck=", v.SCK)
+	str += fmt.Sprint(" cs=", v.CS)
+	str += fmt.Sprint(" buffered=", v.rx.len())
+	str += fmt.Sprint(" queued=", v.tx.len())
+	if v.overrun {
+		str += " overrun"
+	}
+	if v.overruns > 0 {
+		str += fmt.Sprint(" overruns=", v.overruns)
+	}
 	return str + ">"
 }
@@ -0,0 +1,280 @@
+//go:build pico
+
+package pico
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	// Module imports
+	interrupt "runtime/interrupt"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SPITransfer is an asynchronous transfer on an SPI interface, which uses
+// DMA to move frames between memory and the FIFOs. Each interface has one
+// transfer, so a transfer is only valid until the next one is started.
+type SPITransfer struct {
+	spi      *SPI
+	state    uint32
+	w, r     unsafe.Pointer // Buffers, which are kept until the transfer completes
+	zero     uint16         // Source of zeros when reading, or sink when writing
+	callback SPI_callback_t
+}
+
+// SPI_callback_t is called from an interrupt handler when an asynchronous
+// transfer has completed and CS has been released
+type SPI_callback_t func(*SPI)
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	_SPI_TRANSFER_DONE    = 0
+	_SPI_TRANSFER_ACTIVE  = 1
+	_SPI_TRANSFER_ABORTED = 2
+)
+
+const (
+	_SPI_DMA_IRQ = 0 // DMA interrupt for completion of transfers
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	spi_dma [NUM_DMA_CHANNELS]*SPI // Interface with a transfer, by RX channel
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Start writing w and reading into r in a single transaction, and return
+// without waiting for it to complete. Either can be nil, in which case zeros
+// are written or data read is discarded. If both are set they must be the
+// same length. The buffers must not be changed until the transfer is done.
+// The callback, which can be nil, is called from an interrupt handler when
+// the transfer has completed.
+//
+// Any transfer in progress is waited for first. Frames must be sent most
+// significant bit first, otherwise ErrNotImplemented is returned.
+func (s *SPI) TxAsync(w, r []byte, callback SPI_callback_t) (*SPITransfer, error) {
+	if err := assert(w == nil || r == nil || len(w) == len(r), ErrBadParameter.With("TxAsync")); err != nil {
+		return nil, err
+	}
+	var wp, rp unsafe.Pointer
+	n := len(w)
+	if n > 0 {
+		wp = unsafe.Pointer(&w[0])
+	}
+	if len(r) > 0 {
+		rp, n = unsafe.Pointer(&r[0]), len(r)
+	}
+	return s.async(wp, rp, uint32(n), DMA_SIZE_8, callback)
+}
+
+// Start writing bytes in a transaction, and return without waiting for it
+// to complete
+func (s *SPI) WriteAsync(w []byte, callback SPI_callback_t) (*SPITransfer, error) {
+	return s.TxAsync(w, nil, callback)
+}
+
+// Start writing w and reading into r in a single transaction, for frames of
+// more than eight bits, and return without waiting for it to complete
+func (s *SPI) Tx16Async(w, r []uint16, callback SPI_callback_t) (*SPITransfer, error) {
+	if err := assert(w == nil || r == nil || len(w) == len(r), ErrBadParameter.With("Tx16Async")); err != nil {
+		return nil, err
+	}
+	var wp, rp unsafe.Pointer
+	n := len(w)
+	if n > 0 {
+		wp = unsafe.Pointer(&w[0])
+	}
+	if len(r) > 0 {
+		rp, n = unsafe.Pointer(&r[0]), len(r)
+	}
+	return s.async(wp, rp, uint32(n), DMA_SIZE_16, callback)
+}
+
+// Start writing half words in a transaction, and return without waiting for
+// it to complete
+func (s *SPI) Write16Async(w []uint16, callback SPI_callback_t) (*SPITransfer, error) {
+	return s.Tx16Async(w, nil, callback)
+}
+
+// Return true if the transfer has completed or been aborted
+func (t *SPITransfer) Done() bool {
+	return atomic.LoadUint32(&t.state) != _SPI_TRANSFER_ACTIVE
+}
+
+// Wait for the transfer to complete. Returns ErrTimeout if it does not
+// complete within the timeout, or ErrAborted if it was aborted. A timeout of
+// zero waits forever.
+func (t *SPITransfer) Wait(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for !t.Done() {
+		if timeout > 0 && time.Now().After(deadline) {
+			return ErrTimeout.With("SPI:", t.spi.Num)
+		}
+		runtime.Gosched()
+	}
+	if atomic.LoadUint32(&t.state) == _SPI_TRANSFER_ABORTED {
+		return ErrAborted.With("SPI:", t.spi.Num)
+	}
+	return nil
+}
+
+// Abort the transfer if it has not completed, discard any frames received
+// and release CS. The callback is not called.
+func (t *SPITransfer) Abort() {
+	s := t.spi
+	state := interrupt.Disable()
+	if t.Done() {
+		interrupt.Restore(state)
+		return
+	}
+	spi_dma[s.dma_rx] = nil
+	DMA_channel_abort(s.dma_tx)
+	DMA_channel_abort(s.dma_rx)
+	interrupt.Restore(state)
+
+	// Wait for the frame being shifted, then drain the RX FIFO
+	for SPI_is_busy(s.Num) {
+	}
+	for SPI_is_readable(s.Num) {
+		SPI_get_data(s.Num)
+	}
+	SPI_clear_irq(s.Num, SPI_IRQ_RX_OVERRUN)
+	s.cs(false)
+	t.w, t.r = nil, nil
+	atomic.StoreUint32(&t.state, _SPI_TRANSFER_ABORTED)
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Start a transfer of n frames. A TX channel writes frames from w, or zeros,
+// and an RX channel reads every frame into r, or discards them, so that the
+// RX channel completes only when the last frame has been shifted in.
+func (s *SPI) async(w, r unsafe.Pointer, n uint32, size DMA_channel_transfer_size_t, callback SPI_callback_t) (*SPITransfer, error) {
+	if err := assert(s.order == SPIMSBFirst, ErrNotImplemented.With("SPI:", s.order)); err != nil {
+		return nil, err
+	}
+	s.wait()
+	if err := s.dma_claim(); err != nil {
+		return nil, err
+	}
+
+	// Set up the transfer, which completes immediately if there is nothing
+	// to transfer
+	t := &s.transfer
+	t.spi, t.w, t.r, t.callback = s, w, r, callback
+	if n == 0 {
+		t.finish()
+		return t, nil
+	}
+	atomic.StoreUint32(&t.state, _SPI_TRANSFER_ACTIVE)
+
+	// Configure the TX channel, which reads from a fixed zero when there is
+	// nothing to write
+	c := DMA_channel_get_default_config(s.dma_tx)
+	DMA_channel_config_set_transfer_data_size(c, size)
+	DMA_channel_config_set_dreq(c, SPI_get_dreq(s.Num, true))
+	DMA_channel_config_set_read_increment(c, w != nil)
+	if w == nil {
+		t.zero, w = 0, unsafe.Pointer(&t.zero)
+	}
+	DMA_channel_configure(s.dma_tx, c, SPI_get_data_addr(s.Num), w, n, false)
+
+	// Configure the RX channel, which writes to a fixed sink when data read
+	// is discarded
+	c = DMA_channel_get_default_config(s.dma_rx)
+	DMA_channel_config_set_transfer_data_size(c, size)
+	DMA_channel_config_set_dreq(c, SPI_get_dreq(s.Num, false))
+	DMA_channel_config_set_read_increment(c, false)
+	DMA_channel_config_set_write_increment(c, r != nil)
+	if r == nil {
+		r = unsafe.Pointer(&t.zero)
+	}
+	DMA_channel_configure(s.dma_rx, c, r, SPI_get_data_addr(s.Num), n, false)
+
+	// Assert chip select and start both channels together
+	spi_dma[s.dma_rx] = s
+	s.cs(true)
+	DMA_start_channel_mask(1<<s.dma_tx | 1<<s.dma_rx)
+
+	// Return the transfer
+	return t, nil
+}
+
+// Wait for any transfer in progress to complete
+func (s *SPI) wait() {
+	if s.dma {
+		s.transfer.Wait(0)
+	}
+}
+
+// Claim TX and RX channels on first use, and set the callback for the RX
+// channel, which shares the interrupt with other users
+func (s *SPI) dma_claim() error {
+	if s.dma {
+		return nil
+	}
+	tx, ok := DMA_claim_unused_channel()
+	if !ok {
+		return ErrUnexpectedValue.With("SPI: no DMA channels")
+	}
+	rx, ok := DMA_claim_unused_channel()
+	if !ok {
+		DMA_channel_unclaim(tx)
+		return ErrUnexpectedValue.With("SPI: no DMA channels")
+	}
+	s.dma_tx, s.dma_rx, s.dma = tx, rx, true
+	DMA_irqn_set_channel_callback(_SPI_DMA_IRQ, rx, spi_dma_irq_handler)
+	return nil
+}
+
+// Abort any transfer in progress, disable the interrupt for the RX channel
+// and release the channels
+func (s *SPI) dma_release() {
+	if !s.dma {
+		return
+	}
+	s.transfer.Abort()
+	DMA_irqn_set_channel_callback(_SPI_DMA_IRQ, s.dma_rx, nil)
+	DMA_channel_unclaim(s.dma_tx)
+	DMA_channel_unclaim(s.dma_rx)
+	s.dma = false
+}
+
+// Release CS once the last frame has been shifted out, mark the transfer as
+// done and call the callback
+func (t *SPITransfer) finish() {
+	s := t.spi
+	for SPI_is_busy(s.Num) {
+	}
+	s.cs(false)
+	t.w, t.r = nil, nil
+	atomic.StoreUint32(&t.state, _SPI_TRANSFER_DONE)
+	if t.callback != nil {
+		t.callback(s)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS - INTERRUPTS
+
+func spi_dma_irq_handler(channel uint32) {
+	if s := spi_dma[channel]; s != nil {
+		spi_dma[channel] = nil
+		s.transfer.finish()
+	}
+}
@@ -4,19 +4,32 @@ package pico
 
 import (
 	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
 	. "github.com/djthorpe/go-pico/pkg/sdk"
 )
 
 //////////////////////////////////////////////////////////////////////////////
 // TYPES
 
+// SPI represents a Serial Peripheral Interface in master mode. It satisfies
+// the drivers.SPI interface, so it can be used with TinyGo driver packages.
+// The CS pin is asserted (low) around each transaction.
 type SPI struct {
-	Num  uint32
-	RX   Pin
-	TX   Pin
-	SCK  Pin
-	CS   Pin
-	Baud uint32
+	Num   uint32
+	RX    Pin
+	TX    Pin
+	SCK   Pin
+	CS    Pin
+	Baud  uint32
+	mode  SPIMode
+	order SPIOrder
+	bits  uint8
+
+	// Asynchronous transfers
+	dma      bool   // True when DMA channels have been claimed
+	dma_tx   uint32 // DMA channel which writes frames to the TX FIFO
+	dma_rx   uint32 // DMA channel which reads frames from the RX FIFO
+	transfer SPITransfer
 }
 
 //////////////////////////////////////////////////////////////////////////////
@@ -24,6 +37,11 @@ type SPI struct {
 
 const (
 	SPI_DEFAULT_BAUD_RATE = 1000000
+	SPI_DEFAULT_DATA_BITS = 8
+)
+
+const (
+	_SPI_CHUNK = 16 // Number of frames which are reordered at a time
 )
 
 //////////////////////////////////////////////////////////////////////////////
@@ -35,9 +53,245 @@ func _NewSPI(config SPI) *SPI {
 		config.Baud = SPI_DEFAULT_BAUD_RATE
 	}
 
-	// Initialise SPI
+	// Initialise SPI, which sets mode 0 with 8-bit frames
 	config.Baud = SPI_init(config.Num, config.Baud)
+	config.mode, config.order, config.bits = SPIMode0, SPIMSBFirst, SPI_DEFAULT_DATA_BITS
 
 	// Return success
 	return &config
 }
+
+// Wait for any asynchronous transfer to complete, release the DMA channels
+// and disable the interface
+func (s *SPI) Close() error {
+	s.wait()
+	s.dma_release()
+	SPI_deinit(s.Num)
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set the mode, bit order and number of bits in each frame, which is between
+// SPI_MIN_DATA_BITS and SPI_MAX_DATA_BITS
+func (s *SPI) SetFormat(mode SPIMode, order SPIOrder, bits uint8) error {
+	if err := spi_check_format(mode, order, bits); err != nil {
+		return err
+	}
+	s.wait()
+
+	// The hardware only sends the most significant bit first, so the bit order
+	// is reversed in software
+	cpol, cpha := SPI_CPOL_0, SPI_CPHA_0
+	if mode.CPOL() {
+		cpol = SPI_CPOL_1
+	}
+	if mode.CPHA() {
+		cpha = SPI_CPHA_1
+	}
+	SPI_set_format(s.Num, bits, cpol, cpha, SPI_MSB_FIRST)
+	s.mode, s.order, s.bits = mode, order, bits
+
+	// Return success
+	return nil
+}
+
+// Return the mode, bit order and number of bits in each frame
+func (s *SPI) Format() (SPIMode, SPIOrder, uint8) {
+	return s.mode, s.order, s.bits
+}
+
+// Write w and read into r in a single transaction. Either can be nil, in which
+// case zeros are written or data read is discarded. If both are set they must
+// be the same length. Any asynchronous transfer in progress is waited for
+// first.
+func (s *SPI) Tx(w, r []byte) error {
+	if err := assert(w == nil || r == nil || len(w) == len(r), ErrBadParameter.With("Tx")); err != nil {
+		return err
+	}
+	s.wait()
+	s.cs(true)
+	defer s.cs(false)
+	switch {
+	case w == nil:
+		s.read(r)
+	case r == nil:
+		s.write(w)
+	default:
+		s.tx(w, r)
+	}
+	return nil
+}
+
+// Write and read a single byte in a transaction
+func (s *SPI) Transfer(b byte) (byte, error) {
+	var buf [1]byte
+	if err := s.Tx([]byte{b}, buf[:]); err != nil {
+		return 0, err
+	}
+	return buf[0], nil
+}
+
+// Write bytes in a transaction, and return the number of bytes written
+func (s *SPI) Write(w []byte) (int, error) {
+	if err := s.Tx(w, nil); err != nil {
+		return 0, err
+	}
+	return len(w), nil
+}
+
+// Read bytes in a transaction while writing zeros, and return the number of
+// bytes read
+func (s *SPI) Read(r []byte) (int, error) {
+	if err := s.Tx(nil, r); err != nil {
+		return 0, err
+	}
+	return len(r), nil
+}
+
+// Write w and read into r in a single transaction, for frames of more than
+// eight bits. Either can be nil, in which case zeros are written or data read
+// is discarded. If both are set they must be the same length.
+func (s *SPI) Tx16(w, r []uint16) error {
+	if err := assert(w == nil || r == nil || len(w) == len(r), ErrBadParameter.With("Tx16")); err != nil {
+		return err
+	}
+	s.wait()
+	s.cs(true)
+	defer s.cs(false)
+	switch {
+	case w == nil:
+		s.read16(r)
+	case r == nil:
+		s.write16(w)
+	default:
+		s.tx16(w, r)
+	}
+	return nil
+}
+
+// Write half words in a transaction, and return the number written
+func (s *SPI) Write16(w []uint16) (int, error) {
+	if err := s.Tx16(w, nil); err != nil {
+		return 0, err
+	}
+	return len(w), nil
+}
+
+// Read half words in a transaction while writing zeros, and return the
+// number read
+func (s *SPI) Read16(r []uint16) (int, error) {
+	if err := s.Tx16(nil, r); err != nil {
+		return 0, err
+	}
+	return len(r), nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Return an error if the format is not supported
+func spi_check_format(mode SPIMode, order SPIOrder, bits uint8) error {
+	if err := assert(mode <= SPIMode3, ErrBadParameter.With("SetFormat:", mode)); err != nil {
+		return err
+	}
+	if err := assert(order <= SPILSBFirst, ErrBadParameter.With("SetFormat:", order)); err != nil {
+		return err
+	}
+	if err := assert(bits >= SPI_MIN_DATA_BITS && bits <= SPI_MAX_DATA_BITS, ErrBadParameter.With("SetFormat:", bits)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Assert or deassert chip select, which is active low
+func (s *SPI) cs(active bool) {
+	s.CS.Set(!active)
+}
+
+// Transfer bytes, reordering bits in chunks when sending LSB first
+func (s *SPI) tx(w, r []byte) {
+	if s.order == SPIMSBFirst {
+		SPI_write_read_blocking(s.Num, w, r)
+		return
+	}
+	var buf [_SPI_CHUNK]byte
+	for len(w) > 0 {
+		n := copy(buf[:], w)
+		s.reorder(buf[:n])
+		SPI_write_read_blocking(s.Num, buf[:n], r[:n])
+		s.reorder(r[:n])
+		w, r = w[n:], r[n:]
+	}
+}
+
+func (s *SPI) write(w []byte) {
+	if s.order == SPIMSBFirst {
+		SPI_write_blocking(s.Num, w)
+		return
+	}
+	var buf [_SPI_CHUNK]byte
+	for len(w) > 0 {
+		n := copy(buf[:], w)
+		s.reorder(buf[:n])
+		SPI_write_blocking(s.Num, buf[:n])
+		w = w[n:]
+	}
+}
+
+func (s *SPI) read(r []byte) {
+	SPI_read_blocking(s.Num, 0, r)
+	if s.order == SPILSBFirst {
+		s.reorder(r)
+	}
+}
+
+func (s *SPI) tx16(w, r []uint16) {
+	if s.order == SPIMSBFirst {
+		SPI_write16_read16_blocking(s.Num, w, r)
+		return
+	}
+	var buf [_SPI_CHUNK]uint16
+	for len(w) > 0 {
+		n := copy(buf[:], w)
+		s.reorder16(buf[:n])
+		SPI_write16_read16_blocking(s.Num, buf[:n], r[:n])
+		s.reorder16(r[:n])
+		w, r = w[n:], r[n:]
+	}
+}
+
+func (s *SPI) write16(w []uint16) {
+	if s.order == SPIMSBFirst {
+		SPI_write16_blocking(s.Num, w)
+		return
+	}
+	var buf [_SPI_CHUNK]uint16
+	for len(w) > 0 {
+		n := copy(buf[:], w)
+		s.reorder16(buf[:n])
+		SPI_write16_blocking(s.Num, buf[:n])
+		w = w[n:]
+	}
+}
+
+func (s *SPI) read16(r []uint16) {
+	SPI_read16_blocking(s.Num, 0, r)
+	if s.order == SPILSBFirst {
+		s.reorder16(r)
+	}
+}
+
+// Reorder the bits of each frame in place
+func (s *SPI) reorder(buf []byte) {
+	for i, v := range buf {
+		buf[i] = byte(s.order.Frame(uint16(v), s.bits))
+	}
+}
+
+func (s *SPI) reorder16(buf []uint16) {
+	for i, v := range buf {
+		buf[i] = s.order.Frame(v, s.bits)
+	}
+}
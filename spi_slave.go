@@ -0,0 +1,287 @@
+//go:build pico
+
+package pico
+
+import (
+	// Module imports
+	rp "device/rp"
+	interrupt "runtime/interrupt"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SPISlave is an SPI interface in peripheral mode, which responds to an
+// external controller when its CS pin is asserted. Frames received are moved
+// into a buffer by an interrupt handler, and frames queued with Write are sent
+// in order. When no frames are queued the TX FIFO underruns and the data sent
+// is not defined, so the controller should only rely on frames it knows have
+// been queued.
+type SPISlave struct {
+	Num      uint32
+	RX       Pin
+	TX       Pin
+	SCK      Pin
+	CS       Pin
+	rx       *ring
+	tx       *ring
+	overrun  bool
+	overruns uint32
+	callback SPISlave_callback_t
+}
+
+type SPISlave_callback_t func(s *SPISlave)
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	SPI_SLAVE_BUFFER_SIZE = 256 // Number of frames buffered in each direction
+)
+
+var (
+	spi_slave      [NUM_SPIS]*SPISlave
+	spi_slave_intr = [NUM_SPIS]interrupt.Interrupt{
+		interrupt.New(rp.IRQ_SPI0_IRQ, spi0_intr_handler),
+		interrupt.New(rp.IRQ_SPI1_IRQ, spi1_intr_handler),
+	}
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Return an SPI interface in peripheral mode for the RX pin, as for
+// Pin.SPI. The CS pin is an input. With mode 0 or 2 (CPHA=0) the controller
+// must deassert CS between frames.
+func NewSPISlave(pin Pin, mode SPIMode, bits uint8) (*SPISlave, error) {
+	// Check parameters
+	config, exists := map_spi[pin]
+	if !exists {
+		return nil, ErrBadParameter.With("NewSPISlave:", pin)
+	}
+	if err := spi_check_format(mode, SPIMSBFirst, bits); err != nil {
+		return nil, err
+	}
+	if err := assert(spi_slave[config.Num] == nil, ErrDuplicateValue.With("NewSPISlave:", pin)); err != nil {
+		return nil, err
+	}
+
+	// Initialise the interface, which is enabled as a controller by SPI_init,
+	// and disable it while the format and peripheral mode are set
+	s := &SPISlave{
+		Num: config.Num,
+		RX:  config.RX,
+		TX:  config.TX,
+		SCK: config.SCK,
+		CS:  config.CS,
+		rx:  _NewRing(SPI_SLAVE_BUFFER_SIZE),
+		tx:  _NewRing(SPI_SLAVE_BUFFER_SIZE),
+	}
+	SPI_init(s.Num, SPI_DEFAULT_BAUD_RATE)
+	SPI_set_enabled(s.Num, false)
+	cpol, cpha := SPI_CPOL_0, SPI_CPHA_0
+	if mode.CPOL() {
+		cpol = SPI_CPOL_1
+	}
+	if mode.CPHA() {
+		cpha = SPI_CPHA_1
+	}
+	SPI_set_format(s.Num, bits, cpol, cpha, SPI_MSB_FIRST)
+	SPI_set_slave(s.Num, true)
+	SPI_set_enabled(s.Num, true)
+
+	// Enable receive interrupts
+	spi_slave[s.Num] = s
+	SPI_clear_irq(s.Num, SPI_IRQ_RX_OVERRUN|SPI_IRQ_RX_TIMEOUT)
+	SPI_set_irq_mask(s.Num, SPI_IRQ_RX|SPI_IRQ_RX_TIMEOUT|SPI_IRQ_RX_OVERRUN)
+	spi_slave_intr[s.Num].Enable()
+
+	// Set pins, including CS which is driven by the controller, once the
+	// interface is in peripheral mode
+	for _, pin := range []Pin{config.RX, config.TX, config.SCK, config.CS} {
+		if err := pin.SetMode(ModeSPI); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+
+	// Return success
+	return s, nil
+}
+
+// Disable the interface
+func (s *SPISlave) Close() error {
+	spi_slave_intr[s.Num].Disable()
+	SPI_set_irq_mask(s.Num, 0)
+	SPI_deinit(s.Num)
+	spi_slave[s.Num] = nil
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set a callback, which is called from the interrupt handler when frames have
+// been received. If called with nil then the callback is disabled.
+func (s *SPISlave) SetInterrupt(callback SPISlave_callback_t) {
+	s.callback = callback
+}
+
+// Queue frames to be sent, and return the number of frames queued. Returns
+// ErrOverflow if the queue is full and not all frames could be queued.
+func (s *SPISlave) Write16(w []uint16) (int, error) {
+	n := 0
+	for _, v := range w {
+		if !s.tx.push(v) {
+			break
+		}
+		n++
+	}
+	s.fill()
+	if n < len(w) {
+		return n, ErrOverflow.With("Write16")
+	}
+	return n, nil
+}
+
+// Queue bytes to be sent, and return the number of bytes queued. Returns
+// ErrOverflow if the queue is full and not all bytes could be queued.
+func (s *SPISlave) Write(w []byte) (int, error) {
+	n := 0
+	for _, v := range w {
+		if !s.tx.push(uint16(v)) {
+			break
+		}
+		n++
+	}
+	s.fill()
+	if n < len(w) {
+		return n, ErrOverflow.With("Write")
+	}
+	return n, nil
+}
+
+// Read received frames, and return the number of frames read. ErrOverflow is
+// returned if frames have been dropped since the last read, because the buffer
+// or FIFO was full.
+func (s *SPISlave) Read16(r []uint16) (int, error) {
+	n := s.rx.read(r)
+	return n, s.overflow("Read16")
+}
+
+// Read received frames as bytes, and return the number of bytes read.
+// ErrOverflow is returned if frames have been dropped since the last read.
+func (s *SPISlave) Read(r []byte) (int, error) {
+	n := 0
+	for n < len(r) {
+		if v, ok := s.rx.pop(); !ok {
+			break
+		} else {
+			r[n] = byte(v)
+			n++
+		}
+	}
+	return n, s.overflow("Read")
+}
+
+// Return the number of frames which have been received and not yet read
+func (s *SPISlave) Buffered() int {
+	return s.rx.len()
+}
+
+// Return the number of frames which are queued and not yet moved to the FIFO
+func (s *SPISlave) Queued() int {
+	return s.tx.len()
+}
+
+// Return the number of receive overruns since the interface was created, which
+// counts each overrun of the FIFO reported by the hardware and each frame
+// dropped because the buffer was full
+func (s *SPISlave) Overruns() uint32 {
+	return s.overruns
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Return and clear the overrun error
+func (s *SPISlave) overflow(op string) error {
+	if s.overrun {
+		s.overrun = false
+		return ErrOverflow.With(op)
+	}
+	return nil
+}
+
+// Move queued frames into the TX FIFO, and enable the TX interrupt while
+// frames remain queued
+func (s *SPISlave) fill() {
+	state := interrupt.Disable()
+	defer interrupt.Restore(state)
+	for SPI_is_writable(s.Num) {
+		if v, ok := s.tx.pop(); !ok {
+			break
+		} else {
+			SPI_put_data(s.Num, v)
+		}
+	}
+	mask := SPI_get_irq_mask(s.Num)
+	if s.tx.len() > 0 {
+		SPI_set_irq_mask(s.Num, mask|SPI_IRQ_TX)
+	} else {
+		SPI_set_irq_mask(s.Num, mask&^SPI_IRQ_TX)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS - INTERRUPTS
+
+func spi0_intr_handler(interrupt.Interrupt) {
+	spi_slave_intr_handler(spi_slave[0])
+}
+
+func spi1_intr_handler(interrupt.Interrupt) {
+	spi_slave_intr_handler(spi_slave[1])
+}
+
+// Interrupt handler, which drains the RX FIFO into the buffer and fills the
+// TX FIFO from the queue
+func spi_slave_intr_handler(s *SPISlave) {
+	if s == nil {
+		return
+	}
+	status := SPI_get_irq_status(s.Num)
+
+	// Receive overrun, a frame was dropped by the hardware
+	if status&SPI_IRQ_RX_OVERRUN != 0 {
+		s.overrun = true
+		s.overruns++
+	}
+	SPI_clear_irq(s.Num, SPI_IRQ_RX_OVERRUN|SPI_IRQ_RX_TIMEOUT)
+
+	// Drain the RX FIFO
+	received := false
+	for SPI_is_readable(s.Num) {
+		if !s.rx.push(SPI_get_data(s.Num)) {
+			s.overrun = true
+			s.overruns++
+		}
+		received = true
+	}
+
+	// Fill the TX FIFO
+	if status&SPI_IRQ_TX != 0 {
+		s.fill()
+	}
+
+	// Call the callback
+	if received {
+		if fn := s.callback; fn != nil {
+			fn(s)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package pico_test
+
+import (
+	"testing"
+
+	// Namespace import
+	. "github.com/djthorpe/go-pico"
+)
+
+func Test_SPI_001(t *testing.T) {
+	// Clock polarity and phase for each mode
+	tests := []struct {
+		mode       SPIMode
+		cpol, cpha bool
+	}{
+		{SPIMode0, false, false},
+		{SPIMode1, false, true},
+		{SPIMode2, true, false},
+		{SPIMode3, true, true},
+	}
+	for _, test := range tests {
+		if test.mode.CPOL() != test.cpol || test.mode.CPHA() != test.cpha {
+			t.Errorf("Mode %d: CPOL=%v CPHA=%v", test.mode, test.mode.CPOL(), test.mode.CPHA())
+		}
+	}
+}
+
+func Test_SPI_002(t *testing.T) {
+	// Bit order of frames
+	tests := []struct {
+		order    SPIOrder
+		v        uint16
+		bits     uint8
+		expected uint16
+	}{
+		{SPIMSBFirst, 0xA5, 8, 0xA5},
+		{SPIMSBFirst, 0x1A5, 8, 0xA5},
+		{SPILSBFirst, 0x01, 8, 0x80},
+		{SPILSBFirst, 0xA0, 8, 0x05},
+		{SPILSBFirst, 0x1, 4, 0x8},
+		{SPILSBFirst, 0x8001, 16, 0x8001},
+		{SPILSBFirst, 0x0003, 16, 0xC000},
+		{SPILSBFirst, 0x0003, 12, 0x0C00},
+	}
+	for _, test := range tests {
+		if v := test.order.Frame(test.v, test.bits); v != test.expected {
+			t.Errorf("Frame(0x%X, %d) = 0x%X, expected 0x%X", test.v, test.bits, v, test.expected)
+		}
+		if test.v < 1<<test.bits {
+			if v := test.order.Frame(test.order.Frame(test.v, test.bits), test.bits); v != test.v {
+				t.Errorf("Frame is not reversible for 0x%X", test.v)
+			}
+		}
+	}
+}
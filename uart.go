@@ -0,0 +1,16 @@
+package pico
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// UARTParity determines whether a parity bit is sent with each frame
+type UARTParity uint8
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	UARTParityNone UARTParity = iota
+	UARTParityEven
+	UARTParityOdd
+)
@@ -0,0 +1,311 @@
+//go:build pico
+
+package pico
+
+import (
+	"runtime"
+	"time"
+
+	// Module imports
+	rp "device/rp"
+	interrupt "runtime/interrupt"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// UARTBuffered is a UART where bytes received are moved into a buffer by an
+// interrupt handler, so they are not lost when the FIFO is not read in time.
+// Bytes written are queued, and moved into the FIFO by the interrupt handler
+// as space becomes available.
+type UARTBuffered struct {
+	*UART
+	rx      *ring
+	tx      *ring
+	errors  UARTErrors
+	timeout time.Duration
+	line    *lines
+}
+
+// UARTErrors counts the errors in received data
+type UARTErrors struct {
+	Overrun uint32 // Bytes lost because the FIFO or buffer was full
+	Framing uint32 // Bytes received without a valid stop bit
+	Parity  uint32 // Bytes received with a parity error
+	Break   uint32 // Break conditions received
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	uart_buffered      [NUM_UARTS]*UARTBuffered
+	uart_buffered_intr = [NUM_UARTS]interrupt.Interrupt{
+		interrupt.New(rp.IRQ_UART0_IRQ, uart0_intr_handler),
+		interrupt.New(rp.IRQ_UART1_IRQ, uart1_intr_handler),
+	}
+)
+
+const (
+	UART_DEFAULT_BUFFER_SIZE = 256 // Default number of bytes buffered in each direction
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Return a buffered UART for the TX pin, as for Pin.UART, which buffers up
+// to size bytes in each direction. If size is zero, UART_DEFAULT_BUFFER_SIZE
+// is used.
+func NewUARTBuffered(pin Pin, size int) (*UARTBuffered, error) {
+	// Check parameters
+	if size == 0 {
+		size = UART_DEFAULT_BUFFER_SIZE
+	}
+	if err := assert(size > 0, ErrBadParameter.With("NewUARTBuffered:", size)); err != nil {
+		return nil, err
+	}
+	config, exists := map_uart[pin]
+	if !exists {
+		return nil, ErrBadParameter.With("NewUARTBuffered:", pin)
+	}
+	if err := assert(uart_buffered[config.Num] == nil, ErrDuplicateValue.With("NewUARTBuffered:", pin)); err != nil {
+		return nil, err
+	}
+
+	// Set pins and initialise the UART
+	uart, err := _GPIO.uart(pin)
+	if err != nil {
+		return nil, err
+	}
+	u := &UARTBuffered{
+		UART: uart,
+		rx:   _NewRing(size),
+		tx:   _NewRing(size),
+		line: _NewLines(size),
+	}
+
+	// Interrupt when the RX FIFO is half full or when data has been waiting,
+	// and when the TX FIFO is half empty
+	uart_buffered[u.Num] = u
+	UART_set_fifo_levels(u.Num, UART_FIFO_LEVEL_1_2, UART_FIFO_LEVEL_1_2)
+	UART_clear_irq(u.Num, UART_IRQ_RX|UART_IRQ_RX_TIME|UART_IRQ_OVERRUN)
+	UART_set_irq_mask(u.Num, UART_IRQ_RX|UART_IRQ_RX_TIME|UART_IRQ_OVERRUN)
+	uart_buffered_intr[u.Num].Enable()
+
+	// Return success
+	return u, nil
+}
+
+// Wait for queued data to be sent, and then disable the UART
+func (u *UARTBuffered) Close() error {
+	u.Flush()
+	uart_buffered_intr[u.Num].Disable()
+	UART_set_irq_mask(u.Num, 0)
+	uart_buffered[u.Num] = nil
+	return u.UART.Close()
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set the read timeout. Read and ReadLine return ErrTimeout if no data is
+// received within the timeout. If zero, reads block until data is received.
+func (u *UARTBuffered) SetReadTimeout(timeout time.Duration) {
+	u.timeout = timeout
+}
+
+// Read bytes, blocking until at least one byte has been received or the read
+// timeout has passed, and return the number of bytes read
+func (u *UARTBuffered) Read(r []byte) (int, error) {
+	if len(r) == 0 {
+		return 0, nil
+	}
+	if err := u.wait(u.deadline()); err != nil {
+		return 0, err
+	}
+	n := 0
+	for n < len(r) {
+		if v, ok := u.rx.pop(); !ok {
+			break
+		} else {
+			r[n] = byte(v)
+			n++
+		}
+	}
+	u.line.reset()
+	return n, nil
+}
+
+// Read a line, which ends with CR, LF or CRLF, and return it without the line
+// ending. A line which is longer than the buffer is returned in parts. If no
+// line ending is received within the read timeout, ErrTimeout is returned and
+// the partial line is kept for the next call.
+func (u *UARTBuffered) ReadLine() (string, error) {
+	deadline := u.deadline()
+	for {
+		if err := u.wait(deadline); err != nil {
+			return "", err
+		}
+		for {
+			v, ok := u.rx.pop()
+			if !ok {
+				break
+			}
+			if line, ok := u.line.push(byte(v)); ok {
+				return line, nil
+			}
+		}
+	}
+}
+
+// Queue bytes to be sent, blocking while the buffer is full, and return the
+// number of bytes written. With RS-485, blocks until the bytes have been sent.
+func (u *UARTBuffered) Write(w []byte) (int, error) {
+	if u.rs485 {
+		u.driver(true)
+		defer u.driver(false)
+		defer u.Flush()
+	}
+	for _, v := range w {
+		for !u.tx.push(uint16(v)) {
+			u.fill()
+			runtime.Gosched()
+		}
+	}
+	u.fill()
+	return len(w), nil
+}
+
+// Wait until all data queued has been sent
+func (u *UARTBuffered) Flush() error {
+	for u.tx.len() > 0 {
+		u.fill()
+		runtime.Gosched()
+	}
+	return u.UART.Flush()
+}
+
+// Send a break, after waiting for data queued to be sent
+func (u *UARTBuffered) Break(duration time.Duration) error {
+	u.Flush()
+	return u.UART.Break(duration)
+}
+
+// Return true if there is data waiting to be read
+func (u *UARTBuffered) Readable() bool {
+	return u.rx.len() > 0
+}
+
+// Return the number of bytes which have been received and not yet read
+func (u *UARTBuffered) Buffered() int {
+	return u.rx.len()
+}
+
+// Return the number of bytes which are queued and not yet moved to the FIFO
+func (u *UARTBuffered) Queued() int {
+	return u.tx.len()
+}
+
+// Return the error counts since the UART was created
+func (u *UARTBuffered) Errors() UARTErrors {
+	state := interrupt.Disable()
+	defer interrupt.Restore(state)
+	return u.errors
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Return the time when the read timeout passes, or zero if there is no timeout
+func (u *UARTBuffered) deadline() time.Time {
+	if u.timeout > 0 {
+		return time.Now().Add(u.timeout)
+	}
+	return time.Time{}
+}
+
+// Wait until data has been received, or return ErrTimeout once the deadline
+// has passed, so that ReadLine times out while bytes without a line ending
+// keep arriving. A zero deadline waits forever.
+func (u *UARTBuffered) wait(deadline time.Time) error {
+	for {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return ErrTimeout.With("UART:", u.Num)
+		} else if u.rx.len() > 0 {
+			return nil
+		}
+		runtime.Gosched()
+	}
+}
+
+// Move queued bytes into the TX FIFO, and enable the TX interrupt while
+// bytes remain queued
+func (u *UARTBuffered) fill() {
+	state := interrupt.Disable()
+	defer interrupt.Restore(state)
+	for UART_is_writable(u.Num) {
+		if v, ok := u.tx.pop(); !ok {
+			break
+		} else {
+			UART_put_data(u.Num, uint8(v))
+		}
+	}
+	mask := UART_get_irq_mask(u.Num)
+	if u.tx.len() > 0 {
+		UART_set_irq_mask(u.Num, mask|UART_IRQ_TX)
+	} else {
+		UART_set_irq_mask(u.Num, mask&^UART_IRQ_TX)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS - INTERRUPTS
+
+func uart0_intr_handler(interrupt.Interrupt) {
+	uart_buffered_intr_handler(uart_buffered[0])
+}
+
+func uart1_intr_handler(interrupt.Interrupt) {
+	uart_buffered_intr_handler(uart_buffered[1])
+}
+
+// Interrupt handler, which drains the RX FIFO into the buffer, counting
+// errors, and fills the TX FIFO from the queue
+func uart_buffered_intr_handler(u *UARTBuffered) {
+	if u == nil {
+		return
+	}
+	status := UART_get_irq_status(u.Num)
+	UART_clear_irq(u.Num, UART_IRQ_RX_TIME|UART_IRQ_OVERRUN)
+
+	// Drain the RX FIFO. A break is received as a zero byte, which is
+	// discarded.
+	for UART_is_readable(u.Num) {
+		v := UART_get_data(u.Num)
+		if v&UART_DATA_OVERRUN != 0 {
+			u.errors.Overrun++
+		}
+		if v&UART_DATA_BREAK != 0 {
+			u.errors.Break++
+			continue
+		}
+		if v&UART_DATA_FRAME != 0 {
+			u.errors.Framing++
+		}
+		if v&UART_DATA_PARITY != 0 {
+			u.errors.Parity++
+		}
+		if !u.rx.push(uint16(v & 0xFF)) {
+			u.errors.Overrun++
+		}
+	}
+
+	// Fill the TX FIFO
+	if status&UART_IRQ_TX != 0 {
+		u.fill()
+	}
+}
@@ -0,0 +1,50 @@
+//go:build debug
+
+package pico
+
+import "fmt"
+
+//////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (v *UART) String() string {
+	str := "<uart"
+	str += fmt.Sprint(" num=", v.Num)
+	if v.Baud > 0 {
+		str += fmt.Sprint(" baud=", v.Baud)
+	}
+	str += fmt.Sprint(" tx=", v.TX)
+	str += fmt.Sprint(" rx=", v.RX)
+	str += fmt.Sprintf(" format=%d%v%d", v.data, v.parity, v.stop)
+	if v.flow {
+		str += fmt.Sprint(" cts=", v.CTS, " rts=", v.RTS)
+	}
+	if v.rs485 {
+		str += fmt.Sprint(" de=", v.de)
+	}
+	return str + ">"
+}
+
+func (p UARTParity) String() string {
+	switch p {
+	case UARTParityNone:
+		return "N"
+	case UARTParityEven:
+		return "E"
+	case UARTParityOdd:
+		return "O"
+	default:
+		return "?"
+	}
+}
+
+func (v *UARTBuffered) String() string {
+	str := "<uartbuffered"
+	str += fmt.Sprint(" uart=", v.UART)
+	str += fmt.Sprint(" buffered=", v.Buffered())
+	str += fmt.Sprint(" queued=", v.Queued())
+	if v.errors != (UARTErrors{}) {
+		str += fmt.Sprintf(" errors=%+v", v.errors)
+	}
+	return str + ">"
+}
@@ -0,0 +1,65 @@
+package pico
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// lines splits received bytes into lines, which end with CR, LF or CRLF. A
+// line which is longer than the buffer is returned in parts.
+type lines struct {
+	buf   []byte
+	cr    bool // True if the last line ended with CR
+	split bool // True if the last line was returned because the buffer was full
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Create a line buffer which holds lines of up to size bytes
+func _NewLines(size int) *lines {
+	return &lines{buf: make([]byte, 0, size)}
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Add a byte, and return a line without the line ending and true when a line
+// is complete
+func (l *lines) push(b byte) (string, bool) {
+	switch {
+	case b == '\n' && l.cr:
+		// LF following CR is part of the same line ending
+		l.cr = false
+		return "", false
+	case b == '\r' || b == '\n':
+		l.cr = b == '\r'
+		if l.split && len(l.buf) == 0 {
+			// The line was returned when the buffer became full
+			l.split = false
+			return "", false
+		}
+		return l.take(), true
+	default:
+		l.cr = false
+		l.buf = append(l.buf, b)
+		if len(l.buf) == cap(l.buf) {
+			line := l.take()
+			l.split = true
+			return line, true
+		}
+		return "", false
+	}
+}
+
+// Forget the line ending of the last line, when bytes have been read other
+// than as lines, so that a following LF is not taken as part of a CRLF
+func (l *lines) reset() {
+	l.cr, l.split = false, false
+}
+
+// Return the line read so far, and start a new line
+func (l *lines) take() string {
+	line := string(l.buf)
+	l.buf = l.buf[:0]
+	l.split = false
+	return line
+}
@@ -0,0 +1,85 @@
+package pico
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Push bytes into a line buffer, and return the lines completed
+func push_lines(l *lines, data string) []string {
+	result := []string{}
+	for i := 0; i < len(data); i++ {
+		if line, ok := l.push(data[i]); ok {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+func Test_Lines_001(t *testing.T) {
+	// Lines end with CR, LF or CRLF, and empty lines are returned
+	tests := []struct {
+		data     string
+		expected []string
+	}{
+		{"abc", []string{}},
+		{"abc\r", []string{"abc"}},
+		{"abc\n", []string{"abc"}},
+		{"abc\r\n", []string{"abc"}},
+		{"a\rb\nc\r\nd\r", []string{"a", "b", "c", "d"}},
+		{"\n\n", []string{"", ""}},
+		{"\r\r", []string{"", ""}},
+		{"\r\n\r\n", []string{"", ""}},
+		{"a\n\rb\r", []string{"a", "", "b"}},
+	}
+	for _, test := range tests {
+		if lines := push_lines(_NewLines(16), test.data); !reflect.DeepEqual(lines, test.expected) {
+			t.Errorf("%q: expected %q, got %q", test.data, test.expected, lines)
+		}
+	}
+}
+
+func Test_Lines_002(t *testing.T) {
+	// A partial line is kept between calls, and CRLF can be split
+	l := _NewLines(16)
+	if lines := push_lines(l, "hel"); len(lines) != 0 {
+		t.Error("Unexpected lines", lines)
+	}
+	if lines := push_lines(l, "lo\r"); !reflect.DeepEqual(lines, []string{"hello"}) {
+		t.Error("Unexpected lines", lines)
+	}
+	if lines := push_lines(l, "\nworld\n"); !reflect.DeepEqual(lines, []string{"world"}) {
+		t.Error("Unexpected lines", lines)
+	}
+}
+
+func Test_Lines_003(t *testing.T) {
+	// A line which is longer than the buffer is returned in parts
+	tests := []struct {
+		data     string
+		expected []string
+	}{
+		{"abcdefg\n", []string{"abcd", "efg"}},
+		{"abcdefghij\r\n", []string{"abcd", "efgh", "ij"}},
+		// A line which fills the buffer exactly is not followed by an empty line
+		{"abcd\r\n", []string{"abcd"}},
+		{"abcdefgh\n\n", []string{"abcd", "efgh", ""}},
+	}
+	for _, test := range tests {
+		if lines := push_lines(_NewLines(4), test.data); !reflect.DeepEqual(lines, test.expected) {
+			t.Errorf("%q: expected %q, got %q", test.data, test.expected, lines)
+		}
+	}
+}
+
+func Test_Lines_004(t *testing.T) {
+	// After a reset, LF following CR ends an empty line
+	l := _NewLines(16)
+	if lines := push_lines(l, "abc\r"); !reflect.DeepEqual(lines, []string{"abc"}) {
+		t.Error("Unexpected lines", lines)
+	}
+	l.reset()
+	if lines := push_lines(l, "\ndef\n"); !reflect.DeepEqual(lines, []string{"", "def"}) {
+		t.Error("Unexpected lines", lines)
+	}
+}
@@ -0,0 +1,208 @@
+//go:build pico
+
+package pico
+
+import (
+	"time"
+
+	// Namespace imports
+	. "github.com/djthorpe/go-pico/pkg/errors"
+	. "github.com/djthorpe/go-pico/pkg/sdk"
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// UART represents a Universal Asynchronous Receiver/Transmitter. It
+// implements io.Reader and io.Writer. The CTS and RTS pins are only used
+// when flow control is enabled.
+type UART struct {
+	Num    uint32
+	TX     Pin
+	RX     Pin
+	CTS    Pin
+	RTS    Pin
+	Baud   uint32
+	data   uint8
+	stop   uint8
+	parity UARTParity
+	flow   bool
+	rs485  bool
+	de     Pin // RS-485 driver enable
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+const (
+	UART_DEFAULT_BAUD_RATE = PICO_DEFAULT_UART_BAUD_RATE
+	UART_DEFAULT_DATA_BITS = 8
+	UART_DEFAULT_STOP_BITS = 1
+)
+
+//////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func _NewUART(config UART) (*UART, error) {
+	// Check the pins are a TX and RX pair on the instance
+	if inst, ok := UART_gpio_to_inst(GPIO_pin(config.TX), GPIO_pin(config.RX)); !ok || inst != config.Num {
+		return nil, ErrBadParameter.With("UART:", config.TX, ",", config.RX)
+	}
+
+	// Set default baud rate
+	if config.Baud == 0 {
+		config.Baud = UART_DEFAULT_BAUD_RATE
+	}
+
+	// Initialise UART, which sets 8 data bits, 1 stop bit and no parity
+	if baud := UART_init(config.Num, config.Baud); baud == 0 {
+		return nil, ErrNotInitialised.With("UART:", config.Num)
+	} else {
+		config.Baud = baud
+	}
+	config.data, config.stop, config.parity = UART_DEFAULT_DATA_BITS, UART_DEFAULT_STOP_BITS, UARTParityNone
+
+	// Return success
+	return &config, nil
+}
+
+// Wait for data to be sent, and then disable the UART
+func (u *UART) Close() error {
+	UART_tx_wait_blocking(u.Num)
+	UART_deinit(u.Num)
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Set the baud rate. The actual rate is set in the Baud field.
+func (u *UART) SetBaud(baud uint32) error {
+	if err := assert(baud > 0, ErrBadParameter.With("SetBaud:", baud)); err != nil {
+		return err
+	}
+	u.Baud = UART_set_baudrate(u.Num, baud)
+	return nil
+}
+
+// Set the number of data bits (5 to 8), stop bits (1 or 2) and parity
+func (u *UART) SetFormat(data, stop uint8, parity UARTParity) error {
+	if err := assert(data >= UART_MIN_DATA_BITS && data <= UART_MAX_DATA_BITS, ErrBadParameter.With("SetFormat:", data)); err != nil {
+		return err
+	}
+	if err := assert(stop >= UART_MIN_STOP_BITS && stop <= UART_MAX_STOP_BITS, ErrBadParameter.With("SetFormat:", stop)); err != nil {
+		return err
+	}
+	if err := assert(parity <= UARTParityOdd, ErrBadParameter.With("SetFormat:", parity)); err != nil {
+		return err
+	}
+	UART_set_format(u.Num, uint32(data), uint32(stop), UART_parity_t(parity))
+	u.data, u.stop, u.parity = data, stop, parity
+	return nil
+}
+
+// Return the number of data bits, stop bits and parity
+func (u *UART) Format() (uint8, uint8, UARTParity) {
+	return u.data, u.stop, u.parity
+}
+
+// Enable or disable CTS/RTS hardware flow control, which sets the function
+// of the CTS and RTS pins. When enabled, data is only sent while CTS is
+// asserted (low), and RTS is asserted while there is space in the RX FIFO.
+func (u *UART) SetFlowControl(enabled bool) error {
+	mode := ModeOff
+	if enabled {
+		mode = ModeUART
+	}
+	for _, pin := range []Pin{u.CTS, u.RTS} {
+		if err := pin.SetMode(mode); err != nil {
+			return err
+		}
+	}
+	UART_set_hw_flow(u.Num, enabled, enabled)
+	u.flow = enabled
+	return nil
+}
+
+// Enable or disable RS-485 direction control. The driver enable pin is high
+// while data is sent and low otherwise, so it can drive the DE and /RE inputs
+// of a transceiver. When enabled, writes block until the data has been sent.
+func (u *UART) SetRS485(de Pin, enabled bool) error {
+	if u.rs485 {
+		if err := u.de.SetMode(ModeOff); err != nil {
+			return err
+		}
+		u.rs485 = false
+	}
+	if enabled {
+		if err := de.SetMode(ModeOutput); err != nil {
+			return err
+		}
+		de.Set(false)
+		u.de, u.rs485 = de, true
+	}
+	return nil
+}
+
+// Write bytes, blocking until they have all been queued in the FIFO, and
+// return the number of bytes written. With RS-485, blocks until the bytes
+// have been sent.
+func (u *UART) Write(w []byte) (int, error) {
+	u.driver(true)
+	defer u.driver(false)
+	UART_write_blocking(u.Num, w)
+	return len(w), nil
+}
+
+// Read bytes, blocking until at least one byte has been received, and return
+// the number of bytes read
+func (u *UART) Read(r []byte) (int, error) {
+	if len(r) == 0 {
+		return 0, nil
+	}
+	r[0] = UART_getc(u.Num)
+	n := 1
+	for n < len(r) && UART_is_readable(u.Num) {
+		r[n] = UART_getc(u.Num)
+		n++
+	}
+	return n, nil
+}
+
+// Return true if there is data waiting to be read
+func (u *UART) Readable() bool {
+	return UART_is_readable(u.Num)
+}
+
+// Wait until all data written has been sent
+func (u *UART) Flush() error {
+	UART_tx_wait_blocking(u.Num)
+	return nil
+}
+
+// Send a break, which holds the TX line low for a duration, after waiting for
+// data written to be sent
+func (u *UART) Break(duration time.Duration) error {
+	UART_tx_wait_blocking(u.Num)
+	u.driver(true)
+	defer u.driver(false)
+	UART_set_break(u.Num, true)
+	time.Sleep(duration)
+	UART_set_break(u.Num, false)
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// Assert or release the RS-485 driver. The driver is released once the last
+// byte has left the shift register.
+func (u *UART) driver(active bool) {
+	if !u.rs485 {
+		return
+	}
+	if !active {
+		UART_tx_wait_blocking(u.Num)
+	}
+	u.de.Set(active)
+}